@@ -11,10 +11,124 @@ const (
 	TimeFormat        = "2006-01-02 15:04:05"
 )
 
+const (
+	ComponentMiner = "miner"
+	ComponentChain = "chain"
+	ComponentTee   = "tee"
+)
+
 const (
 	Size1kib = 1024
 	Size1mib = 1024 * Size1kib
 	Size1gib = 1024 * Size1mib
+	Size1tib = 1024 * Size1gib
+)
+
+const (
+	TokenDecimals = 18
+	BlocksPerEra  = 3600 // 6 sessions of 600 blocks
+)
+
+const (
+	SpaceForecastWindow    = 7 * 24 * 3600  // unit: second
+	SpaceHistoryRetention  = 30 * 24 * 3600 // unit: second
+	SpaceHistoryMaxSamples = 2000           // per miner
+	SpaceForecastMinSpan   = 3600           // samples must span at least an hour to forecast, unit: second
+)
+
+const (
+	TaskDiscovery = "discovery"
+	TaskStats     = "stats"
+	TaskChain     = "chain"
+	TaskProbe     = "probe"
+	// default, minimum and maximum interval of each task, unit: second
+	DefaultDiscoveryInterval = 300
+	MinDiscoveryInterval     = 30
+	MaxDiscoveryInterval     = 3600
+	DefaultStatsInterval     = 60
+	MinStatsInterval         = 10
+	MaxStatsInterval         = 3600
+	DefaultChainInterval     = 600
+	MinChainInterval         = 120
+	MaxChainInterval         = 3600
+	DefaultProbeInterval     = 120
+	MinProbeInterval         = 30
+	MaxProbeInterval         = 3600
+	DefaultScheduleJitter    = 10 // unit: percent
+	MaxScheduleJitter        = 50
+	ScheduleStartDelay       = 10 // unit: second, the clients start at a random delay up to this so they do not start together
+)
+
+const (
+	DefaultChainQueryWorkers = 4
+	MaxChainQueryWorkers     = 32
+	DefaultChainQueryQPS     = 20
+	MaxChainQueryQPS         = 500
+)
+
+const (
+	ProbeModeAuto         = "auto"
+	ProbeModeExec         = "exec"   // probe inside the miner container by docker exec
+	ProbeModeDirect       = "direct" // probe from watchdog
+	DefaultProbeTimeout   = 5        // unit: second
+	ProbeFailureThreshold = 2        // consecutive failures before an endpoint is considered down
+	DefaultRpcMaxLag      = 20       // unit: block
+)
+
+const (
+	DefaultDiskUsagePercent  = 90
+	DefaultInodeUsagePercent = 90
+	DefaultDiskFullHours     = 24
+	DiskGrowthWindow         = 24 * 3600 // unit: second
+)
+
+const (
+	ChainRpcPort             = 9944
+	DefaultChainMaxLag       = 20  // unit: block
+	DefaultChainStallMinutes = 10  // about 100 blocks
+	DefaultChainFinalityLag  = 100 // unit: block
+)
+
+// a miner is punished in the call submitting the proof verify result if its proof is incorrect,
+// and when the challenge ends if it submitted no proof
+const (
+	PunishmentRetention  = 90 * 24 * 3600
+	PunishmentMaxRecords = 10000
+)
+
+// about an hour, so at least one chain scrape sees a pending proof before its deadline
+const DefaultChallengeWarnBlocks = 600 // unit: block
+
+const (
+	EventRegistration     = "registration"
+	EventExit             = "exit"
+	EventWithdraw         = "withdraw"
+	EventIdleProof        = "idle_proof"
+	EventServiceProof     = "service_proof"
+	EventTagCertification = "tag_certification"
+	EventRewardClaim      = "reward_claim"
+	EventCollateral       = "collateral"
+	EventFrozen           = "frozen"
+	EventUnfrozen         = "unfrozen"
+	EventPunishment       = "punishment"
+	MinerEventRetention   = 30 * 24 * 3600
+	MinerEventMaxPerMiner = 5000
+)
+
+const (
+	DefaultUptimeTarget     = 99.0 // unit: percent
+	UptimeHistoryRetention  = 90 * 24 * 3600
+	UptimeHistoryMaxSamples = 10000
+	UptimeMissingGrace      = 24 * 3600 // a miner missing from a host is down until it has been missing this long
+)
+
+const (
+	AccountRoleSignature     = "signature"
+	AccountRoleStaking       = "staking"
+	AccountRoleEarnings      = "earnings"
+	DefaultMinFeeBalance     = "1" // unit: CESS
+	BalanceHistoryRetention  = 30 * 24 * 3600
+	BalanceHistoryMaxSamples = 2000 // per account
 )
 
 const (
@@ -28,6 +142,43 @@ const (
 	WeChat   = "wechat"
 )
 
+const (
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieApiURL     = "https://api.opsgenie.com"
+	AlertSource        = "cess-watchdog"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+const (
+	AlertKindDockerList  = "docker_list"
+	AlertKindDockerStats = "docker_stats"
+	AlertKindDockerExec  = "docker_exec"
+	AlertKindMinerConfig = "miner_config"
+	AlertKindMinerStatus = "miner_status"
+	AlertKindPunishment  = "punishment"
+	AlertKindTest        = "test"
+	AlertKindDebt        = "miner_debt"
+	AlertKindCollateral  = "low_collateral"
+	AlertKindRewardStall = "reward_stalled"
+	AlertKindSpaceFull   = "space_full"
+	AlertKindLowBalance  = "low_balance"
+	AlertKindTee         = "tee_unreachable"
+	AlertKindMinerPort   = "miner_port_unreachable"
+	AlertKindRpc         = "rpc_unhealthy"
+	AlertKindDisk        = "disk_full"
+	AlertKindImage       = "image_version"
+	AlertKindComponent   = "component_unreachable"
+	AlertKindChainNode   = "chain_node_unhealthy"
+	AlertKindEventMissed = "missing_event"
+	AlertKindProofDue    = "proof_deadline"
+)
+
 const (
 	HttpPostContentType = "application/json"
 	DefaultDescription  = "The Storage Node is not in a positive status or has received punishment"
@@ -39,9 +190,87 @@ const (
 	LocalRpcUrl         = "ws://127.0.0.1:9944"
 )
 
+const (
+	ReportDaily   = "daily"
+	ReportWeekly  = "weekly"
+	ReportTitle   = "CESS Watchdog Report"
+	ReportTmpl    = "report.html"
+	AlertTmpl     = "template.html"
+	ReportDefTime = "08:00"
+)
+
+const (
+	SmtpTLSModeStartTLS = "starttls"
+	SmtpTLSModeTLS      = "tls" // implicit tls, usually on port 465
+	SmtpTLSModeNone     = "none"
+	SmtpImplicitTLSPort = 465
+	SmtpTimeout         = 30 // unit: second
+	SmtpDefSubject      = "[{{.Severity}}] CESS Storage Node Alert on {{.HostIp}}"
+)
+
 const (
 	MinerFrozenStatus    = "Frozen"
+	MinerPositiveStatus  = "positive"
+	MinerExitStatus      = "exit"
 	NoSubmitSvcProof     = "NoSubmitSvcProof"
 	SvcProofResIncorrect = "SvcProofResIncorrect"
 	AlertStaticPath      = "/opt/cess/watchdog/alert/"
+	DataPath             = "/opt/cess/watchdog/data/"
 )
+
+// punishment reasons of the idle proof, the service proof ones are NoSubmitSvcProof and SvcProofResIncorrect
+const (
+	NoSubmitIdleProof     = "NoSubmitIdleProof"
+	IdleProofResIncorrect = "IdleProofResIncorrect"
+)
+
+const (
+	AlertActionTrigger = "trigger"
+	AlertActionResolve = "resolve"
+)
+
+const (
+	AlertStatusPending   = "pending"
+	AlertStatusDelivered = "delivered"
+	AlertStatusPartial   = "partial"
+	AlertStatusFailed    = "failed"
+	AlertStatusMuted     = "muted" // alert is disabled
+	AlertStatusSilenced  = "silenced"
+)
+
+const (
+	MatcherHost      = "host"
+	MatcherGroup     = "group" // group of the host
+	MatcherAccount   = "account"
+	MatcherContainer = "container" // container id or name
+	MatcherKind      = "kind"
+	MatcherSeverity  = "severity"
+)
+
+const (
+	ChannelEmail     = "email"
+	ChannelPagerDuty = "pagerduty"
+	ChannelOpsgenie  = "opsgenie"
+)
+
+const (
+	DefaultAlertMaxAttempts    = 8
+	DefaultAlertInitialBackoff = 10   // unit: second
+	DefaultAlertMaxBackoff     = 3600 // unit: second
+	DefaultAlertRepeatInterval = 240  // unit: minute
+	AlertHistoryMaxSize        = 10000
+	AlertHistoryRetention      = 30 * 24 * 3600 // unit: second
+)
+
+// DefaultChannelRateLimits messages per minute by channel type, keep under the limit of each provider
+var DefaultChannelRateLimits = map[string]int{
+	Discord:          25,
+	Slack:            60,
+	Teams:            30,
+	Lark:             100,
+	DingTalk:         20,
+	WeChat:           20,
+	ChannelEmail:     10,
+	ChannelPagerDuty: 120,
+	ChannelOpsgenie:  60,
+}
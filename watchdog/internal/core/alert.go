@@ -0,0 +1,147 @@
+package core
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sync"
+	"time"
+)
+
+// alertSeverity is the default severity of each alert kind
+var alertSeverity = map[string]string{
+	constant.AlertKindDockerList:  constant.SeverityError,
+	constant.AlertKindDockerStats: constant.SeverityWarning,
+	constant.AlertKindDockerExec:  constant.SeverityWarning,
+	constant.AlertKindMinerConfig: constant.SeverityError,
+	constant.AlertKindMinerStatus: constant.SeverityCritical,
+	constant.AlertKindPunishment:  constant.SeverityCritical,
+	constant.AlertKindDebt:        constant.SeverityCritical,
+	constant.AlertKindCollateral:  constant.SeverityWarning,
+	constant.AlertKindRewardStall: constant.SeverityWarning,
+	constant.AlertKindSpaceFull:   constant.SeverityWarning,
+	constant.AlertKindLowBalance:  constant.SeverityError,
+	constant.AlertKindTee:         constant.SeverityCritical,
+	constant.AlertKindMinerPort:   constant.SeverityError,
+	constant.AlertKindRpc:         constant.SeverityWarning,
+	constant.AlertKindDisk:        constant.SeverityError,
+	constant.AlertKindImage:       constant.SeverityWarning,
+	constant.AlertKindComponent:   constant.SeverityError,
+	constant.AlertKindChainNode:   constant.SeverityError,
+	constant.AlertKindEventMissed: constant.SeverityWarning,
+	constant.AlertKindProofDue:    constant.SeverityCritical,
+}
+
+// eventAlertKinds are the alerts of chain events, each of them is a new event and sent once
+var eventAlertKinds = map[string]bool{
+	constant.AlertKindPunishment: true,
+}
+
+// activeAlerts keeps the triggered alerts by dedup key, a resolve event is only sent for an active alert,
+// and an active alert is not sent again until the repeat interval passes
+var activeAlerts = struct {
+	sync.Mutex
+	m map[string]activeAlert
+}{m: make(map[string]activeAlert)}
+
+type activeAlert struct {
+	content model.AlertContent
+	sentAt  time.Time
+}
+
+func newAlertContent(hostIP string, kind string, message string, signatureAcc string, containerID string, blockNumber uint64) model.AlertContent {
+	severity, ok := alertSeverity[kind]
+	if !ok {
+		severity = constant.SeverityError
+	}
+	return model.AlertContent{
+		AlertTime:     time.Now().Format(constant.TimeFormat),
+		HostIp:        hostIP,
+		Description:   message,
+		SignatureAcc:  signatureAcc,
+		ContainerID:   containerID,
+		ContainerName: containerName(hostIP, containerID, signatureAcc),
+		BlockNumber:   blockNumber,
+		Kind:          kind,
+		Severity:      severity,
+	}
+}
+
+func doAlert(hostIP string, kind string, message string, signatureAcc string, containerID string, blockNumber uint64) {
+	content := newAlertContent(hostIP, kind, message, signatureAcc, containerID, blockNumber)
+	if !CustomConfig.Alert.Enable || GlobalAlertQueue == nil {
+		recordAlert(constant.AlertActionTrigger, content, nil, constant.AlertStatusMuted, "")
+		return
+	}
+	if by, ok := silencedBy(content); ok {
+		log.Logger.Infof("Alert %s is silenced by %s", util.AlertDedupKey(content), by)
+		recordAlert(constant.AlertActionTrigger, content, nil, constant.AlertStatusSilenced, by)
+		return
+	}
+	key := util.AlertDedupKey(content)
+	now := time.Now()
+	activeAlerts.Lock()
+	prev, active := activeAlerts.m[key]
+	repeat := !active || eventAlertKinds[kind] || now.Sub(prev.sentAt) >= time.Duration(CustomConfig.Alert.RepeatInterval)*time.Minute
+	if repeat {
+		activeAlerts.m[key] = activeAlert{content: content, sentAt: now}
+	} else {
+		activeAlerts.m[key] = activeAlert{content: content, sentAt: prev.sentAt}
+	}
+	activeAlerts.Unlock()
+	if !repeat {
+		return
+	}
+
+	channels := routeAlert(content, false)
+	alertID := recordAlert(constant.AlertActionTrigger, content, channels, constant.AlertStatusPending, "")
+	for _, channel := range channels {
+		GlobalAlertQueue.Enqueue(alertID, channel, constant.AlertActionTrigger, content)
+	}
+}
+
+// resolveAlert sends a resolve event to the incident tools when a triggered condition clears
+func resolveAlert(hostIP string, kind string, signatureAcc string, containerID string) {
+	content := newAlertContent(hostIP, kind, "The alert condition has cleared", signatureAcc, containerID, 0)
+	key := util.AlertDedupKey(content)
+	activeAlerts.Lock()
+	_, ok := activeAlerts.m[key]
+	delete(activeAlerts.m, key)
+	activeAlerts.Unlock()
+	if !ok || !CustomConfig.Alert.Enable || GlobalAlertQueue == nil {
+		return
+	}
+	log.Logger.Infof("Alert %s cleared", key)
+	channels := routeAlert(content, true)
+	if len(channels) == 0 {
+		return
+	}
+	alertID := recordAlert(constant.AlertActionResolve, content, channels, constant.AlertStatusPending, "")
+	for _, channel := range channels {
+		GlobalAlertQueue.Enqueue(alertID, channel, constant.AlertActionResolve, content)
+	}
+}
+
+func recordAlert(action string, content model.AlertContent, channels []string, status string, silencedBy string) string {
+	if GlobalAlertHistory == nil {
+		return ""
+	}
+	return GlobalAlertHistory.Record(action, content, channels, status, silencedBy)
+}
+
+// containerName looks up the name of a miner container on the host by container id or signature account
+func containerName(hostIP string, containerID string, signatureAcc string) string {
+	cli, ok := Clients[hostIP]
+	if !ok || cli == nil || containerID == "" && signatureAcc == "" {
+		return ""
+	}
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	for acc, miner := range cli.MinerInfoMap {
+		if containerID != "" && miner.CInfo.ID == containerID || signatureAcc != "" && acc == signatureAcc {
+			return miner.CInfo.Name
+		}
+	}
+	return ""
+}
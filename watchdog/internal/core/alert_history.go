@@ -0,0 +1,176 @@
+package core
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sync"
+	"time"
+)
+
+const alertHistoryStore = "alert_history"
+
+// AlertHistory records every alert produced by doAlert and the delivery result of each channel,
+// it is flushed to the data directory periodically
+type AlertHistory struct {
+	mutex   sync.RWMutex
+	records []model.AlertRecord // ordered by creation time
+	dirty   bool
+}
+
+var GlobalAlertHistory *AlertHistory
+
+func InitAlertHistory() {
+	if GlobalAlertHistory != nil {
+		return
+	}
+	GlobalAlertHistory = &AlertHistory{}
+	if err := store.Load(alertHistoryStore, &GlobalAlertHistory.records); err != nil {
+		log.Logger.Warnf("Failed to load alert history from %s: %v", constant.DataPath, err)
+	}
+	go GlobalAlertHistory.flushLoop()
+}
+
+// Record adds an alert to the history and returns its id
+func (h *AlertHistory) Record(action string, content model.AlertContent, channels []string, status string, silencedBy string) string {
+	now := time.Now().Unix()
+	record := model.AlertRecord{
+		ID:         util.NewID(),
+		Action:     action,
+		Content:    content,
+		Status:     status,
+		SilencedBy: silencedBy,
+		Deliveries: make([]model.AlertDelivery, 0, len(channels)),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for _, channel := range channels {
+		record.Deliveries = append(record.Deliveries, model.AlertDelivery{Channel: channel, Status: constant.AlertStatusPending})
+	}
+	if status == constant.AlertStatusPending && len(channels) == 0 {
+		record.Status = constant.AlertStatusFailed
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.records = append(h.records, record)
+	h.prune(now)
+	h.dirty = true
+	return record.ID
+}
+
+// UpdateDelivery sets the delivery result of one channel and recomputes the status of the alert
+func (h *AlertHistory) UpdateDelivery(alertID string, channel string, status string, attempts int, lastError string) {
+	now := time.Now().Unix()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := len(h.records) - 1; i >= 0; i-- {
+		record := &h.records[i]
+		if record.ID != alertID {
+			continue
+		}
+		for j := range record.Deliveries {
+			d := &record.Deliveries[j]
+			if d.Channel != channel {
+				continue
+			}
+			d.Status = status
+			d.Attempts = attempts
+			d.LastError = lastError
+			if status == constant.AlertStatusDelivered {
+				d.DeliveredAt = now
+			}
+		}
+		record.Status = deliveryStatus(record.Deliveries)
+		record.UpdatedAt = now
+		h.dirty = true
+		return
+	}
+}
+
+// deliveryStatus is pending until every channel finished, then delivered, failed or partial
+func deliveryStatus(deliveries []model.AlertDelivery) string {
+	delivered, failed := 0, 0
+	for _, d := range deliveries {
+		switch d.Status {
+		case constant.AlertStatusDelivered:
+			delivered++
+		case constant.AlertStatusFailed:
+			failed++
+		default:
+			return constant.AlertStatusPending
+		}
+	}
+	switch {
+	case failed == 0:
+		return constant.AlertStatusDelivered
+	case delivered == 0:
+		return constant.AlertStatusFailed
+	default:
+		return constant.AlertStatusPartial
+	}
+}
+
+// Query returns the matched alerts, newest first
+func (h *AlertHistory) Query(filter model.AlertRecordFilter) model.AlertRecordList {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	matched := make([]model.AlertRecord, 0)
+	for i := len(h.records) - 1; i >= 0; i-- {
+		record := h.records[i]
+		if filter.Host != "" && record.Content.HostIp != filter.Host ||
+			filter.Account != "" && record.Content.SignatureAcc != filter.Account ||
+			filter.Severity != "" && record.Content.Severity != filter.Severity ||
+			filter.Kind != "" && record.Content.Kind != filter.Kind ||
+			filter.Status != "" && record.Status != filter.Status ||
+			filter.From > 0 && record.CreatedAt < filter.From ||
+			filter.To > 0 && record.CreatedAt > filter.To {
+			continue
+		}
+		matched = append(matched, record)
+	}
+	res := model.AlertRecordList{Count: len(matched), Content: []model.AlertRecord{}}
+	start := (filter.Page - 1) * filter.PageSize
+	if start < 0 || start >= len(matched) {
+		return res
+	}
+	end := start + filter.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	res.Content = matched[start:end]
+	for i := range res.Content {
+		res.Content[i].Deliveries = append([]model.AlertDelivery(nil), res.Content[i].Deliveries...)
+	}
+	return res
+}
+
+// prune must be called with h.mutex held
+func (h *AlertHistory) prune(now int64) {
+	drop := 0
+	for drop < len(h.records) && now-h.records[drop].CreatedAt > constant.AlertHistoryRetention {
+		drop++
+	}
+	if over := len(h.records) - drop - constant.AlertHistoryMaxSize; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		h.records = append([]model.AlertRecord(nil), h.records[drop:]...)
+	}
+}
+
+func (h *AlertHistory) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mutex.Lock()
+		if h.dirty {
+			if err := store.Save(alertHistoryStore, h.records); err != nil {
+				log.Logger.Errorf("Failed to save alert history to %s: %v", constant.DataPath, err)
+			}
+			h.dirty = false
+		}
+		h.mutex.Unlock()
+	}
+}
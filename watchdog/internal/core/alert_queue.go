@@ -0,0 +1,388 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	alertQueueStore   = "alert_queue"
+	maxDeadLetterSize = 1000
+)
+
+// alertChannel is a single delivery target, each webhook url is a channel of its own
+// so a failing provider does not hold back the others
+type alertChannel struct {
+	name        string
+	channelType string
+	resolvable  bool // only incident tools support resolve events
+	// send returns the http status code of the response if any, only email sends to a part of the receivers,
+	// nil receivers means all receivers
+	send    func(action string, content model.AlertContent, receivers []string) (int, error)
+	limiter *util.RateLimiter
+}
+
+var alertChannels = struct {
+	sync.RWMutex
+	m map[string]*alertChannel
+}{m: make(map[string]*alertChannel)}
+
+// AlertQueue is a persistent outbound queue, alerts are retried with exponential backoff
+// and moved to the dead-letter list after MaxAttempts failures, it is flushed to the data directory periodically
+type AlertQueue struct {
+	mutex      sync.Mutex
+	pending    []model.AlertJob
+	deadLetter []model.AlertJob
+	inFlight   map[string]bool // key: channel name
+	dirty      bool
+}
+
+type alertQueueData struct {
+	Pending    []model.AlertJob `json:"pending"`
+	DeadLetter []model.AlertJob `json:"dead_letter"`
+}
+
+var GlobalAlertQueue *AlertQueue
+
+// InitAlertChannels rebuilds the delivery channels from the current alert config
+func InitAlertChannels() {
+	channels := make(map[string]*alertChannel)
+	if WebhooksConfig != nil {
+		for _, url := range WebhooksConfig.Webhooks {
+			hook, err := util.NewWebhookSender(url)
+			if err != nil {
+				log.Logger.Warn("Unknown webhook type, cannot send webhook alert")
+				continue
+			}
+			name := util.WebhookChannelName(url)
+			channels[name] = newWebhookChannel(name, url, hook)
+		}
+	}
+	for _, named := range CustomConfig.Alert.NamedWebhooks {
+		hook, err := util.NewWebhookSender(named.URL)
+		if err != nil {
+			log.Logger.Warnf("Unknown webhook type of %s, cannot send webhook alert", named.Name)
+			continue
+		}
+		channels[named.Name] = newWebhookChannel(named.Name, named.URL, hook)
+	}
+	if SmtpConfig != nil {
+		smtp := SmtpConfig
+		channels[constant.ChannelEmail] = newAlertChannel(constant.ChannelEmail, constant.ChannelEmail, false, func(action string, content model.AlertContent, receivers []string) (int, error) {
+			return 0, smtp.SendMailTo(content, receivers)
+		})
+	}
+	if PagerDutyConfig != nil {
+		channels[constant.ChannelPagerDuty] = newIncidentChannel(constant.ChannelPagerDuty, PagerDutyConfig)
+	}
+	if OpsgenieConfig != nil {
+		channels[constant.ChannelOpsgenie] = newIncidentChannel(constant.ChannelOpsgenie, OpsgenieConfig)
+	}
+	alertChannels.Lock()
+	alertChannels.m = channels
+	alertChannels.Unlock()
+}
+
+func newAlertChannel(name string, channelType string, resolvable bool, send func(string, model.AlertContent, []string) (int, error)) *alertChannel {
+	perMinute, ok := CustomConfig.Alert.Delivery.RateLimits[channelType]
+	if !ok {
+		perMinute = constant.DefaultChannelRateLimits[channelType]
+	}
+	return &alertChannel{
+		name:        name,
+		channelType: channelType,
+		resolvable:  resolvable,
+		send:        send,
+		limiter:     util.NewRateLimiter(perMinute),
+	}
+}
+
+func newWebhookChannel(name string, url string, hook util.WebhookSender) *alertChannel {
+	return newAlertChannel(name, util.GetWebhookType(url), false, func(action string, content model.AlertContent, _ []string) (int, error) {
+		message, err := util.BuildMessage(content)
+		if err != nil {
+			return 0, err
+		}
+		return hook.SendMessage(message)
+	})
+}
+
+func newIncidentChannel(name string, sender util.IncidentSender) *alertChannel {
+	return newAlertChannel(name, name, true, func(action string, content model.AlertContent, _ []string) (int, error) {
+		if action == constant.AlertActionResolve {
+			return sender.Resolve(content)
+		}
+		return sender.Trigger(content)
+	})
+}
+
+// SendTestAlert sends a sample alert to each configured channel directly, bypassing the queue, silences
+// and rate limits, an incident opened by the test is resolved right away. An empty channel means all channels.
+func SendTestAlert(channel string) ([]model.ChannelTestResult, error) {
+	alertChannels.RLock()
+	channels := make([]*alertChannel, 0, len(alertChannels.m))
+	for name, ch := range alertChannels.m {
+		if channel == "" || channel == name || channel == ch.channelType {
+			channels = append(channels, ch)
+		}
+	}
+	alertChannels.RUnlock()
+	if len(channels) == 0 {
+		if channel == "" {
+			return nil, fmt.Errorf("no alert channel is configured")
+		}
+		return nil, fmt.Errorf("alert channel %s is not configured", channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].name < channels[j].name
+	})
+
+	content := model.AlertContent{
+		AlertTime:   time.Now().Format(constant.TimeFormat),
+		HostIp:      util.GetLocalIP(),
+		Kind:        constant.AlertKindTest,
+		Severity:    constant.SeverityInfo,
+		Description: "This is a test alert from CESS watchdog, please ignore it",
+	}
+	results := make([]model.ChannelTestResult, len(channels))
+	var wg sync.WaitGroup
+	for i, ch := range channels {
+		wg.Add(1)
+		go func(i int, ch *alertChannel) {
+			defer wg.Done()
+			statusCode, err := ch.send(constant.AlertActionTrigger, content, nil)
+			result := model.ChannelTestResult{Channel: ch.name, Type: ch.channelType, Success: err == nil, StatusCode: statusCode}
+			if err != nil {
+				result.Error = err.Error()
+				var httpErr *util.HTTPError
+				if errors.As(err, &httpErr) {
+					result.Body = httpErr.Body
+				}
+			} else if ch.resolvable {
+				if _, err = ch.send(constant.AlertActionResolve, content, nil); err != nil {
+					log.Logger.Warnf("Failed to resolve test alert of %s: %v", ch.name, err)
+				}
+			}
+			results[i] = result
+		}(i, ch)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func getAlertChannel(name string) *alertChannel {
+	alertChannels.RLock()
+	defer alertChannels.RUnlock()
+	return alertChannels.m[name]
+}
+
+// alertChannelNames returns the configured channels matching any of receivers (by name or type),
+// nil receivers means all channels, resolvable only for resolve events
+func alertChannelNames(receivers []string, resolvableOnly bool) []string {
+	alertChannels.RLock()
+	defer alertChannels.RUnlock()
+	channels := make([]util.AlertChannel, 0, len(alertChannels.m))
+	for name, ch := range alertChannels.m {
+		channels = append(channels, util.AlertChannel{Name: name, Type: ch.channelType, Resolvable: ch.resolvable})
+	}
+	return util.SelectChannels(channels, receivers, resolvableOnly)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// InitAlertQueue loads the jobs left from last run and starts the dispatcher
+func InitAlertQueue() {
+	if GlobalAlertQueue != nil {
+		return
+	}
+	var data alertQueueData
+	if err := store.Load(alertQueueStore, &data); err != nil {
+		log.Logger.Warnf("Failed to load alert queue from %s: %v", constant.DataPath, err)
+	}
+	GlobalAlertQueue = &AlertQueue{
+		pending:    data.Pending,
+		deadLetter: data.DeadLetter,
+		inFlight:   make(map[string]bool),
+	}
+	if len(data.Pending) > 0 {
+		log.Logger.Infof("Restored %d pending alerts from last run", len(data.Pending))
+	}
+	go GlobalAlertQueue.dispatch()
+	go GlobalAlertQueue.flushLoop()
+}
+
+func (q *AlertQueue) Enqueue(alertID string, channel string, action string, content model.AlertContent) {
+	now := time.Now().Unix()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.pending = append(q.pending, model.AlertJob{
+		ID:          util.NewID(),
+		AlertID:     alertID,
+		Channel:     channel,
+		Action:      action,
+		Content:     content,
+		NextAttempt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	q.dirty = true
+}
+
+func (q *AlertQueue) dispatch() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		q.dispatchDueJobs()
+	}
+}
+
+// dispatchDueJobs sends at most one job per channel at a time, in order of creation
+func (q *AlertQueue) dispatchDueJobs() {
+	now := time.Now().Unix()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	remaining := q.pending[:0]
+	changed := false
+	for _, job := range q.pending {
+		if job.NextAttempt > now || q.inFlight[job.Channel] {
+			remaining = append(remaining, job)
+			continue
+		}
+		ch := getAlertChannel(job.Channel)
+		if ch == nil {
+			job.LastError = "channel is not configured any more"
+			job.UpdatedAt = now
+			q.deadLetter = util.PushDeadLetter(q.deadLetter, job, maxDeadLetterSize)
+			updateAlertDelivery(job, constant.AlertStatusFailed)
+			changed = true
+			continue
+		}
+		if ch.limiter.Allow() {
+			q.inFlight[job.Channel] = true
+			go q.deliver(ch, job)
+		}
+		remaining = append(remaining, job)
+	}
+	q.pending = remaining
+	if changed {
+		q.dirty = true
+	}
+}
+
+func (q *AlertQueue) deliver(ch *alertChannel, job model.AlertJob) {
+	_, err := ch.send(job.Action, job.Content, job.Receivers)
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.inFlight, job.Channel)
+	idx := -1
+	for i := range q.pending {
+		if q.pending[i].ID == job.ID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	if err == nil {
+		log.Logger.Infof("Alert %s sent to %s successfully", job.Action, job.Channel)
+		q.pending = append(q.pending[:idx], q.pending[idx+1:]...)
+		q.dirty = true
+		job.Attempts++
+		updateAlertDelivery(job, constant.AlertStatusDelivered)
+		return
+	}
+	job = q.pending[idx]
+	if util.FailAlertJob(&job, err, time.Now().Unix(), CustomConfig.Alert.Delivery.MaxAttempts, alertBackoff) {
+		log.Logger.Errorf("Failed to send alert to %s after %d attempts, move it to dead-letter: %v", job.Channel, job.Attempts, err)
+		q.pending = append(q.pending[:idx], q.pending[idx+1:]...)
+		q.deadLetter = util.PushDeadLetter(q.deadLetter, job, maxDeadLetterSize)
+		updateAlertDelivery(job, constant.AlertStatusFailed)
+	} else {
+		log.Logger.Warnf("Failed to send alert to %s, retrying (%d/%d) at %s: %v", job.Channel, job.Attempts,
+			CustomConfig.Alert.Delivery.MaxAttempts, time.Unix(job.NextAttempt, 0).Format(constant.TimeFormat), err)
+		q.pending[idx] = job
+		updateAlertDelivery(job, constant.AlertStatusPending)
+	}
+	q.dirty = true
+}
+
+func updateAlertDelivery(job model.AlertJob, status string) {
+	if GlobalAlertHistory == nil || job.AlertID == "" {
+		return
+	}
+	GlobalAlertHistory.UpdateDelivery(job.AlertID, job.Channel, status, job.Attempts, job.LastError)
+}
+
+// alertBackoff is the wait time of the configured delivery before the next attempt
+func alertBackoff(attempts int) time.Duration {
+	delivery := CustomConfig.Alert.Delivery
+	return util.AlertBackoff(attempts, time.Duration(delivery.InitialBackoff)*time.Second, time.Duration(delivery.MaxBackoff)*time.Second, rand.Float64())
+}
+
+// Resend moves a dead-letter job back to the queue with its attempts reset
+func (q *AlertQueue) Resend(id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, job := range q.deadLetter {
+		if job.ID != id {
+			continue
+		}
+		q.deadLetter = append(q.deadLetter[:i], q.deadLetter[i+1:]...)
+		util.ResetAlertJob(&job, time.Now().Unix())
+		q.pending = append(q.pending, job)
+		updateAlertDelivery(job, constant.AlertStatusPending)
+		q.dirty = true
+		return nil
+	}
+	return fmt.Errorf("dead-letter alert %s not found", id)
+}
+
+func (q *AlertQueue) PendingJobs() []model.AlertJob {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	res := make([]model.AlertJob, len(q.pending))
+	copy(res, q.pending)
+	return res
+}
+
+func (q *AlertQueue) DeadLetterJobs() []model.AlertJob {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	res := make([]model.AlertJob, len(q.deadLetter))
+	copy(res, q.deadLetter)
+	return res
+}
+
+func (q *AlertQueue) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		q.mutex.Lock()
+		if q.dirty {
+			data := alertQueueData{Pending: q.pending, DeadLetter: q.deadLetter}
+			if err := store.Save(alertQueueStore, data); err != nil {
+				log.Logger.Errorf("Failed to save alert queue to %s: %v", constant.DataPath, err)
+			}
+			q.dirty = false
+		}
+		q.mutex.Unlock()
+	}
+}
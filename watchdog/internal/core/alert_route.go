@@ -0,0 +1,46 @@
+package core
+
+import (
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+)
+
+// routeAlert returns the channels an alert should be delivered to, routes are evaluated in order,
+// the alerts no route matched go to the default receivers
+func routeAlert(content model.AlertContent, resolvableOnly bool) []string {
+	receivers := util.RouteReceivers(alertLabels(content), alertRoutes, CustomConfig.Alert.DefaultReceivers)
+	if receivers != nil && len(receivers) == 0 {
+		return []string{}
+	}
+	return alertChannelNames(receivers, resolvableOnly)
+}
+
+// alertRoutes are the valid routes of the current config with the matchers compiled
+var alertRoutes []util.Route
+
+// compileRoutes is called when the config is loaded, it warns about the routes which can never match or deliver
+func compileRoutes(routes []model.AlertRoute) []util.Route {
+	res := make([]util.Route, 0, len(routes))
+	for i, route := range routes {
+		compiled, err := util.CompileRoute(route, alertLabelNames)
+		if err != nil {
+			log.Logger.Warnf("Alert route %d %s will be ignored: %v", i, route.Name, err)
+			continue
+		}
+		if len(route.Receivers) == 0 {
+			log.Logger.Warnf("Alert route %d %s has no receiver, the matched alerts will be dropped", i, route.Name)
+		}
+		res = append(res, compiled)
+	}
+	return res
+}
+
+func hostGroup(hostIP string) string {
+	for _, host := range CustomConfig.Hosts {
+		if host.IP == hostIP {
+			return host.Group
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const balanceHistoryStore = "balance_history"
+
+// BalanceHistory keeps the balance samples of the signature, staking and earnings accounts,
+// an account shared by several miners is sampled once per block, it is flushed to the data directory periodically
+type BalanceHistory struct {
+	mutex   sync.RWMutex
+	samples map[string][]model.BalanceSample // key: account, ordered by time
+	dirty   bool
+}
+
+var GlobalBalanceHistory *BalanceHistory
+
+func InitBalanceHistory() {
+	if GlobalBalanceHistory != nil {
+		return
+	}
+	GlobalBalanceHistory = &BalanceHistory{}
+	if err := store.Load(balanceHistoryStore, &GlobalBalanceHistory.samples); err != nil {
+		log.Logger.Warnf("Failed to load balance history from %s: %v", constant.DataPath, err)
+	}
+	if GlobalBalanceHistory.samples == nil {
+		GlobalBalanceHistory.samples = make(map[string][]model.BalanceSample)
+	}
+	go GlobalBalanceHistory.flushLoop()
+}
+
+func (h *BalanceHistory) Add(account string, sample model.BalanceSample) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	samples := h.samples[account]
+	if n := len(samples); n > 0 && samples[n-1].Block == sample.Block {
+		return
+	}
+	samples = append(samples, sample)
+	drop := 0
+	for drop < len(samples) && sample.Time-samples[drop].Time > constant.BalanceHistoryRetention {
+		drop++
+	}
+	if over := len(samples) - drop - constant.BalanceHistoryMaxSamples; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		samples = append([]model.BalanceSample(nil), samples[drop:]...)
+	}
+	h.samples[account] = samples
+	h.dirty = true
+}
+
+func (h *BalanceHistory) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mutex.Lock()
+		if h.dirty {
+			if err := store.Save(balanceHistoryStore, h.samples); err != nil {
+				log.Logger.Errorf("Failed to save balance history to %s: %v", constant.DataPath, err)
+			}
+			h.dirty = false
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// Samples returns a copy of the samples of an account
+func (h *BalanceHistory) Samples(account string) []model.BalanceSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return append([]model.BalanceSample{}, h.samples[account]...)
+}
+
+// Inflow sums the increases of the total (free + reserved) balance of an account since the given time
+func (h *BalanceHistory) Inflow(account string, since int64) *big.Int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	inflow := new(big.Int)
+	samples := h.samples[account]
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Time < since {
+			continue
+		}
+		delta := new(big.Int).Sub(sampleTotal(samples[i]), sampleTotal(samples[i-1]))
+		if delta.Sign() > 0 {
+			inflow.Add(inflow, delta)
+		}
+	}
+	return inflow
+}
+
+func sampleTotal(sample model.BalanceSample) *big.Int {
+	total, ok := new(big.Int).SetString(sample.Free, 10)
+	if !ok {
+		total = new(big.Int)
+	}
+	if reserved, ok := new(big.Int).SetString(sample.Reserved, 10); ok {
+		total.Add(total, reserved)
+	}
+	return total
+}
+
+// queryBalances queries the balances of the signature, staking and earnings accounts of a miner,
+// and alerts if the free balance of the signature account is too low to pay transaction fees
+func (cli *WatchdogClient) queryBalances(ctx context.Context, hostIP string, signatureAcc string, chainConf model.ChainConfig, block uint32) []model.AccountBalance {
+	accounts := []struct{ role, account string }{
+		{constant.AccountRoleSignature, signatureAcc},
+		{constant.AccountRoleStaking, chainConf.StakingAcc},
+		{constant.AccountRoleEarnings, chainConf.EarningsAcc},
+	}
+	now := time.Now()
+	balances := make([]model.AccountBalance, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.account == "" {
+			continue
+		}
+		// query the latest state, the state of the pinned block may be pruned by the time the query runs
+		info, err := GlobalChainQuery.AccountInfo(ctx, acc.account, -1)
+		if err != nil {
+			log.Logger.Warnf("%s %s failed to query balance of %s account %s: %v", hostIP, signatureAcc, acc.role, acc.account, err)
+			continue
+		}
+		free := util.U128ToBigInt(info.Data.Free)
+		reserved := util.U128ToBigInt(info.Data.Reserved)
+		balance := model.AccountBalance{
+			Role:        acc.role,
+			Account:     acc.account,
+			Free:        util.BigNumConversion(info.Data.Free),
+			Reserved:    util.BigNumConversion(info.Data.Reserved),
+			FreeRaw:     free.String(),
+			ReservedRaw: reserved.String(),
+		}
+		if GlobalBalanceHistory != nil {
+			GlobalBalanceHistory.Add(acc.account, model.BalanceSample{Time: now.Unix(), Block: block, Free: free.String(), Reserved: reserved.String()})
+			if acc.role == constant.AccountRoleEarnings {
+				balance.Inflow24h = util.BigNumConversion(types.NewU128(*GlobalBalanceHistory.Inflow(acc.account, now.Add(-24*time.Hour).Unix())))
+				balance.Inflow7d = util.BigNumConversion(types.NewU128(*GlobalBalanceHistory.Inflow(acc.account, now.AddDate(0, 0, -7).Unix())))
+				balance.Inflow30d = util.BigNumConversion(types.NewU128(*GlobalBalanceHistory.Inflow(acc.account, now.AddDate(0, 0, -30).Unix())))
+			}
+		}
+		if acc.role == constant.AccountRoleSignature {
+			checkFeeBalance(hostIP, signatureAcc, free, block)
+		}
+		balances = append(balances, balance)
+	}
+	return balances
+}
+
+func checkFeeBalance(hostIP string, signatureAcc string, free *big.Int, block uint32) {
+	minFee, err := util.ParseTokenAmount(CustomConfig.Alert.Thresholds.MinFeeBalance)
+	if err != nil {
+		return
+	}
+	if free.Cmp(minFee) < 0 {
+		go doAlert(hostIP, constant.AlertKindLowBalance, fmt.Sprintf("Host: %s, The free balance of signature account %s is %s CESS, lower than %s CESS to pay transaction fees",
+			hostIP, signatureAcc, util.BigNumConversion(types.NewU128(*free)), CustomConfig.Alert.Thresholds.MinFeeBalance), signatureAcc, "", uint64(block))
+	} else {
+		go resolveAlert(hostIP, constant.AlertKindLowBalance, signatureAcc, "")
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chainNodeEndpoints returns the rpc endpoints of the chain nodes on the host
+func (cli *WatchdogClient) chainNodeEndpoints() []string {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	chainSelected := false
+	for _, c := range cli.Components {
+		if c.Component == constant.ComponentChain {
+			chainSelected = true
+			break
+		}
+	}
+	return util.ChainNodeEndpoints(cli.Host, cli.ChainRpcs, chainSelected)
+}
+
+// monitorChainNodes queries the health and sync state of the chain nodes on the host, and alerts if a node
+// is unreachable, stops syncing or falls behind the reference block of the chain query rpc
+func (cli *WatchdogClient) monitorChainNodes(ctx context.Context, reference uint64) {
+	endpoints := cli.chainNodeEndpoints()
+	if len(endpoints) == 0 {
+		return
+	}
+	timeout := probeTimeout()
+	cli.mutex.Lock()
+	prev := cli.ChainNodes
+	cli.mutex.Unlock()
+
+	nodes := make([]model.ChainNodeStatus, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		node := model.ChainNodeStatus{EndpointProbe: model.EndpointProbe{Endpoint: endpoint}}
+		for _, p := range prev {
+			if p.Endpoint == endpoint {
+				node = p
+				break
+			}
+		}
+		wg.Add(1)
+		go func(i int, node model.ChainNodeStatus) {
+			defer wg.Done()
+			nodes[i] = queryChainNode(ctx, node, reference, timeout)
+		}(i, node)
+	}
+	wg.Wait()
+	cli.mutex.Lock()
+	cli.ChainNodes = nodes
+	cli.mutex.Unlock()
+
+	thresholds := CustomConfig.Alert.Thresholds
+	now := time.Now().Unix()
+	for _, node := range nodes {
+		var problems []string
+		switch {
+		case node.Failures >= constant.ProbeFailureThreshold:
+			problems = append(problems, "unreachable: "+node.LastError)
+		case !node.Healthy:
+			continue
+		default:
+			if node.LastProgress > 0 && now-node.LastProgress >= int64(thresholds.ChainStallMinutes)*60 {
+				problems = append(problems, fmt.Sprintf("best block #%d has not increased since %s",
+					node.BestBlock, time.Unix(node.LastProgress, 0).Format(constant.TimeFormat)))
+			}
+			if node.ReferenceBlock > 0 && node.Lag > int64(thresholds.ChainMaxLag) {
+				state := "not syncing"
+				if node.Syncing {
+					state = "syncing"
+				}
+				problems = append(problems, fmt.Sprintf("lags %d blocks behind the chain query rpc (%s)", node.Lag, state))
+			}
+			if node.FinalityLag > int64(thresholds.ChainFinalityLag) {
+				problems = append(problems, fmt.Sprintf("finalized block #%d lags %d blocks behind the best block", node.FinalizedBlock, node.FinalityLag))
+			}
+			if node.Peers == 0 {
+				problems = append(problems, "has no peers")
+			}
+		}
+		if len(problems) > 0 {
+			go doAlert(cli.Host, constant.AlertKindChainNode, fmt.Sprintf("Host: %s, Chain node %s (%s) %s", cli.Host, node.Endpoint,
+				strings.Join(node.Roles, ","), strings.Join(problems, ", ")), "", node.Endpoint, node.BestBlock)
+		} else {
+			go resolveAlert(cli.Host, constant.AlertKindChainNode, "", node.Endpoint)
+		}
+	}
+}
+
+// queryChainNode applies the health, sync state and heights of a chain node to its previous status
+func queryChainNode(ctx context.Context, node model.ChainNodeStatus, reference uint64, timeout time.Duration) model.ChainNodeStatus {
+	start := time.Now()
+	var health struct {
+		Peers     int  `json:"peers"`
+		IsSyncing bool `json:"isSyncing"`
+	}
+	var syncState struct {
+		CurrentBlock uint64 `json:"currentBlock"`
+		HighestBlock uint64 `json:"highestBlock"`
+	}
+	var roles []string
+	err := util.CallJsonRpc(node.Endpoint, "system_health", nil, &health, timeout)
+	if err == nil {
+		err = util.CallJsonRpc(node.Endpoint, "system_syncState", nil, &syncState, timeout)
+	}
+	var finalized uint64
+	if err == nil {
+		finalized, err = util.QueryFinalizedHeight(node.Endpoint, timeout)
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	node.EndpointProbe = updateProbe(node.EndpointProbe, time.Since(start), err)
+	if err != nil {
+		return node
+	}
+	// system_nodeRoles is an unsafe rpc method, which may be denied on public interfaces
+	if util.CallJsonRpc(node.Endpoint, "system_nodeRoles", nil, &roles, timeout) == nil {
+		node.Roles = roles
+	}
+	if syncState.CurrentBlock > node.BestBlock || node.LastProgress == 0 {
+		node.LastProgress = node.LastCheck
+	}
+	node.Syncing = health.IsSyncing
+	node.Peers = health.Peers
+	node.BestBlock = syncState.CurrentBlock
+	node.HighestBlock = syncState.HighestBlock
+	node.FinalizedBlock = finalized
+	node.FinalityLag = int64(node.BestBlock) - int64(finalized)
+	node.ReferenceBlock = reference
+	node.Lag = 0
+	if reference > 0 {
+		node.Lag = int64(reference) - int64(node.BestBlock)
+	}
+	return node
+}
+
+// ChainNodeList returns the status of the chain nodes of the hosts, host is an optional filter
+func ChainNodeList(host string) map[string][]model.ChainNodeStatus {
+	res := make(map[string][]model.ChainNodeStatus)
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		if len(cli.ChainNodes) > 0 {
+			nodes := append([]model.ChainNodeStatus{}, cli.ChainNodes...)
+			sort.Slice(nodes, func(i, j int) bool { return nodes[i].Endpoint < nodes[j].Endpoint })
+			res[hostIP] = nodes
+		}
+		cli.mutex.Unlock()
+	}
+	return res
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"github.com/CESSProject/cess-go-sdk/chain"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/util"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"sync"
+)
+
+// ChainQueryService runs the chain queries of all watchdog clients and the block manager on one chain client,
+// with a bounded worker pool and a limit of the queries per second, so the hosts do not open a connection each
+type ChainQueryService struct {
+	client  *util.CessChainClient
+	jobs    chan func()
+	mutex   sync.Mutex
+	limiter *util.RateLimiter
+	workers int
+	qps     int
+	stop    chan struct{} // closed to stop the current workers when the service is reconfigured
+}
+
+// MinerChainData is the on-chain state of a miner queried at the pinned block of a batch
+type MinerChainData struct {
+	Info      chain.MinerInfo
+	InfoErr   error
+	Reward    chain.MinerReward
+	RewardErr error
+}
+
+var GlobalChainQuery *ChainQueryService
+
+// InitChainQueryService starts the service, and applies the workers and qps of the reloaded config to it,
+// the connection to the rpc is kept
+func InitChainQueryService() {
+	if GlobalChainQuery == nil {
+		GlobalChainQuery = &ChainQueryService{
+			client: util.NewCessChainClient([]string{constant.LocalRpcUrl, constant.DefaultRpcUrl}),
+			jobs:   make(chan func()),
+		}
+	}
+	conf := CustomConfig.ChainQuery
+	GlobalChainQuery.startWorkers(conf.Workers, conf.QPS)
+}
+
+// startWorkers replaces the workers and the rate limiter if the config changed, the queries waiting for
+// a worker are taken by the new workers
+func (s *ChainQueryService) startWorkers(workers int, qps int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop != nil && s.workers == workers && s.qps == qps {
+		return
+	}
+	if s.stop != nil {
+		close(s.stop)
+	}
+	s.stop = make(chan struct{})
+	s.limiter = util.NewRateLimiter(qps * 60)
+	s.workers = workers
+	s.qps = qps
+	for i := 0; i < workers; i++ {
+		go s.work(s.stop)
+	}
+	log.Logger.Infof("Chain query service started with %d workers and %d queries per second", workers, qps)
+}
+
+func (s *ChainQueryService) work(stop chan struct{}) {
+	for {
+		select {
+		case job := <-s.jobs:
+			job()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *ChainQueryService) rateLimiter() *util.RateLimiter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.limiter
+}
+
+// do runs a query on a worker after the rate limiter allows it, and waits for its result
+func (s *ChainQueryService) do(ctx context.Context, query func(chain.Chainer) error) error {
+	done := make(chan error, 1)
+	job := func() {
+		if err := s.rateLimiter().Wait(ctx); err != nil {
+			done <- err
+			return
+		}
+		done <- query(s.client.CessClient)
+	}
+	select {
+	case s.jobs <- job:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ChainQueryService) BlockNumber(ctx context.Context) (uint32, error) {
+	var block uint32
+	err := s.do(ctx, func(c chain.Chainer) (err error) {
+		block, err = c.QueryBlockNumber("")
+		return err
+	})
+	return block, err
+}
+
+func (s *ChainQueryService) AccountInfo(ctx context.Context, account string, block int32) (types.AccountInfo, error) {
+	var info types.AccountInfo
+	err := s.do(ctx, func(c chain.Chainer) (err error) {
+		info, err = c.QueryAccountInfo(account, block)
+		return err
+	})
+	return info, err
+}
+
+func (s *ChainQueryService) ChallengeSnapShot(ctx context.Context, publicKey []byte, block int32) (bool, chain.ChallengeInfo, error) {
+	var challenged bool
+	var info chain.ChallengeInfo
+	err := s.do(ctx, func(c chain.Chainer) (err error) {
+		challenged, info, err = c.QueryChallengeSnapShot(publicKey, block)
+		return err
+	})
+	return challenged, info, err
+}
+
+func (s *ChainQueryService) ParseBlockData(ctx context.Context, block uint64) (chain.BlockData, error) {
+	var data chain.BlockData
+	err := s.do(ctx, func(c chain.Chainer) (err error) {
+		data, err = c.ParseBlockData(block)
+		return err
+	})
+	return data, err
+}
+
+func (s *ChainQueryService) RpcAddr() string {
+	return s.client.CessClient.GetCurrentRpcAddr()
+}
+
+// QueryMiners pins the latest block and queries the miner items and rewards of the miners at it in one batch,
+// so the state of all miners of a host is consistent
+func (s *ChainQueryService) QueryMiners(ctx context.Context, publicKeys [][]byte) (uint32, []MinerChainData, error) {
+	block, err := s.BlockNumber(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	res := make([]MinerChainData, len(publicKeys))
+	var wg sync.WaitGroup
+	for i, publicKey := range publicKeys {
+		wg.Add(2)
+		go func(data *MinerChainData, publicKey []byte) {
+			defer wg.Done()
+			data.InfoErr = s.do(ctx, func(c chain.Chainer) (err error) {
+				data.Info, err = c.QueryMinerItems(publicKey, int32(block))
+				return err
+			})
+		}(&res[i], publicKey)
+		go func(data *MinerChainData, publicKey []byte) {
+			defer wg.Done()
+			data.RewardErr = s.do(ctx, func(c chain.Chainer) (err error) {
+				data.Reward, err = c.QueryRewardMap(publicKey, int32(block))
+				return err
+			})
+		}(&res[i], publicKey)
+	}
+	wg.Wait()
+	return block, res, ctx.Err()
+}
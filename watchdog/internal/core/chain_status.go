@@ -1,9 +1,10 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/CESSProject/cess-go-sdk/chain"
-	"github.com/CESSProject/cess-go-sdk/utils"
+	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/log"
 	"github.com/CESSProject/watchdog/internal/model"
 	"github.com/CESSProject/watchdog/internal/util"
@@ -13,60 +14,74 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (cli *WatchdogClient) SetChainData(signatureAcc string, created int64) (model.MinerStat, error) {
+// SetChainData converts the on-chain state of a miner queried at latestBlockNumber into its stat, and runs the chain checks
+func (cli *WatchdogClient) SetChainData(ctx context.Context, signatureAcc string, publicKey []byte, created int64, chainConf model.ChainConfig, latestBlockNumber uint32, data MinerChainData) (model.MinerStat, error) {
 	var stat model.MinerStat
 	hostIP := cli.Host
 	if hostIP == "" {
 		hostIP = "127.0.0.1"
 	}
 
-	publicKey, err := utils.ParsingPublickey(signatureAcc)
-	if err != nil {
-		return model.MinerStat{}, errors.Wrap(err, "error occurred when parse public key")
+	if data.InfoErr != nil {
+		return model.MinerStat{}, errors.Wrap(data.InfoErr, "error occurred when query minerSignatureAcc stat from chain")
 	}
+	chainInfo := data.Info
 
-	chainInfo, err := cli.CessChainClient.CessClient.QueryMinerItems(publicKey, -1)
-	if err != nil {
-		return model.MinerStat{}, errors.Wrap(err, "error occurred when query minerSignatureAcc stat from chain")
-	}
-
-	stat, err = util.TransferMinerInfoToMinerStat(chainInfo)
+	stat, err := util.TransferMinerInfoToMinerStat(chainInfo)
 	if err != nil {
 		log.Logger.Errorf("%s %s failed to transfer object format", hostIP, signatureAcc)
 		return model.MinerStat{}, err
 	}
 
-	latestBlockNumber, err := cli.CessChainClient.CessClient.QueryBlockNumber("")
-	if err != nil {
-		log.Logger.Errorf("%s %s failed to query latest block", hostIP, signatureAcc)
-		return stat, errors.Wrap(err, "failed to query latest block")
-	}
-
-	if stat.Status != "positive" && time.Now().Unix()-created > 1800 {
-		// do not alert if the miner are firstly created and not active
-		go doAlert(hostIP, fmt.Sprintf("Host: %s, The Status of Storage Node %s on chain is not a positive status", cli.Host, signatureAcc), signatureAcc, "", uint64(latestBlockNumber))
-	}
-
-	reward, err := cli.CessChainClient.CessClient.QueryRewardMap(publicKey, -1)
-	if err != nil {
+	if data.RewardErr != nil {
 		log.Logger.Errorf("%s %s failed to query reward from chain", hostIP, signatureAcc)
-		return stat, errors.Wrap(err, "failed to query reward from chain")
+		return stat, errors.Wrap(data.RewardErr, "failed to query reward from chain")
 	}
+	reward := data.Reward
 	stat.TotalReward = util.BigNumConversion(types.U128(reward.TotalReward))
 	stat.RewardIssued = util.BigNumConversion(types.U128(reward.RewardIssued))
+	stat.TotalRewardRaw = util.U128ToBigInt(types.U128(reward.TotalReward)).String()
+	stat.RewardIssuedRaw = util.U128ToBigInt(types.U128(reward.RewardIssued)).String()
+	go checkMinerFinance(hostIP, signatureAcc, stat.Status, chainInfo, reward, latestBlockNumber)
+	go checkSpaceForecast(hostIP, signatureAcc, stat, latestBlockNumber)
+	stat.Balances = cli.queryBalances(ctx, hostIP, signatureAcc, chainConf, latestBlockNumber)
+	stat.Challenge = cli.checkChallenge(ctx, hostIP, signatureAcc, publicKey, latestBlockNumber)
 
-	stat.LatestPunishInfo = getMinerPunishInfo(GlobalBlockDataManager.BlockDataList, signatureAcc, hostIP)
+	blockDataList := GlobalBlockDataManager.GetBlockDataList()
+	stat.LatestPunishInfo = getMinerPunishInfo(blockDataList, signatureAcc, hostIP, cli.lastChallenge(signatureAcc))
+	recordMinerEvents(hostIP, signatureAcc, stat.Status, created, blockDataList, latestBlockNumber)
+	if len(stat.LatestPunishInfo) == 0 {
+		go resolveAlert(hostIP, constant.AlertKindPunishment, signatureAcc, "")
+	}
 
 	return stat, nil
 }
 
-func getMinerPunishInfo(blockDataList []chain.BlockData, signatureAcc string, hostIp string) []model.PunishSminerData {
+// lastChallenge returns the challenge of a miner known before the current chain query
+func (cli *WatchdogClient) lastChallenge(signatureAcc string) *model.ChallengeStatus {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	if miner, ok := cli.MinerInfoMap[signatureAcc]; ok {
+		return miner.MinerStat.Challenge
+	}
+	return nil
+}
+
+// punishmentDescriptions describe the punishment reasons in the alerts
+var punishmentDescriptions = map[string]string{
+	constant.NoSubmitIdleProof:     "no idle proof submitted",
+	constant.NoSubmitSvcProof:      "no service proof submitted",
+	constant.IdleProofResIncorrect: "incorrect idle proof",
+	constant.SvcProofResIncorrect:  "incorrect service proof",
+}
+
+// getMinerPunishInfo returns the punishments of a miner in the block queue, and alerts each punishment once
+// when it is recorded the first time, challenge is the last known challenge of the miner
+func getMinerPunishInfo(blockDataList []chain.BlockData, signatureAcc string, hostIp string, challenge *model.ChallengeStatus) []model.PunishSminerData {
 	var latestPunishInfo []model.PunishSminerData
 	for _, blockData := range blockDataList {
 		for _, punish := range blockData.Punishment {
 			if punish.From == signatureAcc {
-				log.Logger.Errorf("%s: %s get punishment at block: %d", hostIp, punish.From, blockData.BlockId)
-				go doAlert(hostIp, fmt.Sprint("Storage Node Punishment Event"), signatureAcc, "", uint64(blockData.BlockId))
 				punishData := model.PunishSminerData{
 					BlockId:       blockData.BlockId,
 					ExtrinsicHash: punish.ExtrinsicHash,
@@ -76,6 +91,17 @@ func getMinerPunishInfo(blockDataList []chain.BlockData, signatureAcc string, ho
 					RecvAccount:   punish.To,
 					Amount:        punish.Amount,
 					Timestamp:     blockData.Timestamp,
+					Reason:        util.PunishmentReason(punish.ExtrinsicName, challenge),
+				}
+				isNew := GlobalPunishmentHistory == nil ||
+					GlobalPunishmentHistory.Add(model.PunishmentRecord{PunishSminerData: punishData, Host: hostIp, Time: blockTime(blockData.Timestamp)})
+				if isNew {
+					log.Logger.Errorf("%s: %s get punishment at block: %d", hostIp, punish.From, blockData.BlockId)
+					description, ok := punishmentDescriptions[punishData.Reason]
+					if !ok {
+						description = "punished in " + punishData.Reason
+					}
+					go doAlert(hostIp, constant.AlertKindPunishment, fmt.Sprintf("Storage Node Punishment Event: %s", description), signatureAcc, "", uint64(blockData.BlockId))
 				}
 				latestPunishInfo = append(latestPunishInfo, punishData)
 			}
@@ -83,3 +109,15 @@ func getMinerPunishInfo(blockDataList []chain.BlockData, signatureAcc string, ho
 	}
 	return latestPunishInfo
 }
+
+// blockTime converts the timestamp of a block in millisecond or second to a unix timestamp, now if unknown
+func blockTime(timestamp int64) int64 {
+	switch {
+	case timestamp <= 0:
+		return time.Now().Unix()
+	case timestamp > 1e12:
+		return timestamp / 1000
+	default:
+		return timestamp
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// challengeAlerts keeps the pending proofs already alerted by host and account, so a pending proof is alerted
+// once per challenge instead of on every scrape
+var challengeAlerts = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// checkChallenge queries the current challenge of a miner and alerts if a proof is still not submitted
+// when its deadline is close, so the operator can react before the miner is punished
+func (cli *WatchdogClient) checkChallenge(ctx context.Context, hostIP string, signatureAcc string, publicKey []byte, block uint32) *model.ChallengeStatus {
+	// query the latest state, the state of the pinned block may be pruned by the time the query runs
+	challenged, info, err := GlobalChainQuery.ChallengeSnapShot(ctx, publicKey, -1)
+	if err != nil {
+		log.Logger.Warnf("%s %s failed to query challenge snapshot: %v", hostIP, signatureAcc, err)
+		return nil
+	}
+	status := util.ChallengeStatus(challenged, info, block)
+	status.UpdatedAt = time.Now().Unix()
+
+	warnBlocks := int64(CustomConfig.Alert.Thresholds.ChallengeWarnBlocks)
+	var pending, keys []string
+	describe := func(proof string, deadline uint32, left int64) {
+		keys = append(keys, proof)
+		if left > 0 {
+			pending = append(pending, fmt.Sprintf("%s proof is due at block %d (%d blocks, about %d minutes left)", proof, deadline, left, left*constant.GenBlockInterval/60))
+		} else {
+			pending = append(pending, fmt.Sprintf("%s proof deadline passed at block %d", proof, deadline))
+		}
+	}
+	if status.Challenged && !status.IdleSubmitted && status.IdleBlocksLeft <= warnBlocks {
+		describe("idle", status.IdleDeadline, status.IdleBlocksLeft)
+	}
+	if status.Challenged && !status.ServiceSubmitted && status.ServiceBlocksLeft <= warnBlocks {
+		describe("service", status.ServiceDeadline, status.ServiceBlocksLeft)
+	}
+
+	key := hostIP + "|" + signatureAcc
+	challengeAlerts.Lock()
+	defer challengeAlerts.Unlock()
+	if len(pending) == 0 {
+		if _, ok := challengeAlerts.m[key]; ok {
+			delete(challengeAlerts.m, key)
+			go resolveAlert(hostIP, constant.AlertKindProofDue, signatureAcc, "")
+		}
+		return &status
+	}
+	alerted := fmt.Sprintf("%d:%s", status.Start, strings.Join(keys, ","))
+	if challengeAlerts.m[key] != alerted {
+		challengeAlerts.m[key] = alerted
+		log.Logger.Warnf("%s %s has pending proofs in the challenge started at block %d", hostIP, signatureAcc, status.Start)
+		go doAlert(hostIP, constant.AlertKindProofDue, fmt.Sprintf("Proof not submitted yet: %s", strings.Join(pending, ", ")), signatureAcc, "", uint64(block))
+	}
+	return &status
+}
+
+// ChallengeList returns the current challenge of the monitored miners, host is an optional filter
+func ChallengeList(host string) []model.MinerChallenge {
+	res := []model.MinerChallenge{}
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		for acc, miner := range cli.MinerInfoMap {
+			if miner.MinerStat.Challenge == nil {
+				continue
+			}
+			res = append(res, model.MinerChallenge{
+				Host:            hostIP,
+				SignatureAcc:    acc,
+				Name:            miner.CInfo.Name,
+				ChallengeStatus: *miner.MinerStat.Challenge,
+			})
+		}
+		cli.mutex.Unlock()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
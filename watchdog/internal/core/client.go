@@ -13,7 +13,6 @@ import (
 	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
 	"github.com/docker/docker/api/types"
 	"math/rand"
-	"strings"
 	"sync"
 	"time"
 )
@@ -31,9 +30,8 @@ type BlockDataManager struct {
 	BlockDataList []chain.BlockData // Chain block data list, shared among all clients, acts as a FIFO queue
 	blockDataMap  map[uint64]bool   // Map to track which blocks are already in the queue
 	mutex         sync.RWMutex      // Mutex for protecting BlockDataList and blockDataMap
-	chainClient   *util.CessChainClient
-	maxQueueSize  int    // Maximum number of blocks to maintain in the queue
-	latestBlock   uint64 // Latest known block number
+	maxQueueSize  int               // Maximum number of blocks to maintain in the queue
+	latestBlock   uint64            // Latest known block number
 	active        bool
 	initialized   bool // Flag to indicate if queue has been initially populated
 }
@@ -42,14 +40,18 @@ type BlockDataManager struct {
 var GlobalBlockDataManager *BlockDataManager
 
 type WatchdogClient struct {
-	Host                  string                // 127.0.0.1 or some ip else
-	*Client                                     // docker cli
-	*util.HTTPClient                            // http cli
-	*util.CessChainClient                       // cess chain cli
-	MinerInfoMap          map[string]*MinerInfo // key: miner-name
-	Updating              bool                  // is miners data updating?
-	Active                bool                  // sleep or run
-	mutex                 sync.Mutex
+	Host             string                    // 127.0.0.1 or some ip else
+	*Client                                    // docker cli
+	*util.HTTPClient                           // http cli
+	MinerInfoMap     map[string]*MinerInfo     // key: miner-name
+	Components       map[string]*ComponentInfo // other monitored components, key: container id
+	Selectors        []*util.Selector          // select the monitored containers
+	ChainRpcs        []string                  // rpc endpoints of the chain nodes on the host
+	ChainNodes       []model.ChainNodeStatus   // health and sync state of the chain nodes
+	Active           bool                      // sleep or run
+	mutex            sync.Mutex
+	cancel           context.CancelFunc // stops the scheduled tasks
+	dockerDown       bool               // the last discovery failed to list the containers
 }
 
 var Clients = map[string]*WatchdogClient{} // key: hostIP
@@ -59,6 +61,12 @@ type MinerInfo struct {
 	Conf         model.MinerConfigFile
 	CInfo        model.Container
 	MinerStat    model.MinerStat
+	Tees         []model.EndpointProbe // reachability of the tees in the miner config
+	ServicePort  *model.EndpointProbe  // reachability of the miner service port or api endpoint
+	RPCs         []model.RpcProbe      // reachability and block height of the rpcs in the miner config
+	Disk         *model.DiskUsage      // usage of the filesystem holding the workspace
+	Image        *model.ImageVersion   // tag and digest of the image the container runs
+	diskSamples  []diskSample
 }
 
 // InitBlockDataManager initializes the global block data manager with a queue structure
@@ -67,9 +75,6 @@ func InitBlockDataManager(interval int) {
 		return
 	}
 
-	// Initialize the block data manager with chain client
-	chainClient := util.NewCessChainClient([]string{constant.LocalRpcUrl, constant.DefaultRpcUrl})
-
 	// Calculate queue size based on interval and block generation time
 	maxQueueSize := interval / constant.GenBlockInterval
 	if maxQueueSize <= 0 {
@@ -79,7 +84,6 @@ func InitBlockDataManager(interval int) {
 	GlobalBlockDataManager = &BlockDataManager{
 		BlockDataList: make([]chain.BlockData, 0, maxQueueSize),
 		blockDataMap:  make(map[uint64]bool),
-		chainClient:   chainClient,
 		maxQueueSize:  maxQueueSize,
 		latestBlock:   0,
 		active:        true,
@@ -100,7 +104,7 @@ func InitBlockDataManager(interval int) {
 // initialQueuePopulation fills the queue with initial block data
 func (bdm *BlockDataManager) initialQueuePopulation() error {
 	// Get the latest block number
-	latestBlockNumber, err := bdm.chainClient.CessClient.QueryBlockNumber("")
+	latestBlockNumber, err := GlobalChainQuery.BlockNumber(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to query block number during initialization: %w", err)
 	}
@@ -119,10 +123,10 @@ func (bdm *BlockDataManager) initialQueuePopulation() error {
 	bdm.mutex.Lock()
 	defer bdm.mutex.Unlock()
 
-	log.Logger.Infof("start to fetch block data from %s", bdm.chainClient.CessClient.GetCurrentRpcAddr())
+	log.Logger.Infof("start to fetch block data from %s", GlobalChainQuery.RpcAddr())
 	for i := startBlockNum; i <= int64(latestBlockNum); i++ {
 		blockNum := uint64(i)
-		data, err := bdm.chainClient.CessClient.ParseBlockData(blockNum)
+		data, err := GlobalChainQuery.ParseBlockData(context.Background(), blockNum)
 		if err != nil {
 			log.Logger.Warnf("Failed to parse block data for block %d during initialization: %v", blockNum, err)
 			continue
@@ -131,7 +135,7 @@ func (bdm *BlockDataManager) initialQueuePopulation() error {
 		bdm.BlockDataList = append(bdm.BlockDataList, data)
 		bdm.blockDataMap[blockNum] = true
 		if i%100 == 0 {
-			log.Logger.Infof("Fetch block data from %s, current block num %d", bdm.chainClient.CessClient.GetCurrentRpcAddr(), i)
+			log.Logger.Infof("Fetch block data from %s, current block num %d", GlobalChainQuery.RpcAddr(), i)
 		}
 	}
 
@@ -153,7 +157,7 @@ func (bdm *BlockDataManager) watchNewBlocks() {
 
 	for bdm.active {
 		// Query the latest block number
-		latestBlockNumber, err := bdm.chainClient.CessClient.QueryBlockNumber("")
+		latestBlockNumber, err := GlobalChainQuery.BlockNumber(context.Background())
 		if err != nil {
 			log.Logger.Warnf("Failed to query latest block number: %v", err)
 			time.Sleep(checkInterval)
@@ -183,7 +187,7 @@ func (bdm *BlockDataManager) watchNewBlocks() {
 // processNewBlock fetches and processes a new block, adding it to the queue
 func (bdm *BlockDataManager) processNewBlock(blockNum uint64) error {
 	// Fetch block data
-	data, err := bdm.chainClient.CessClient.ParseBlockData(blockNum)
+	data, err := GlobalChainQuery.ParseBlockData(context.Background(), blockNum)
 	if err != nil {
 		return fmt.Errorf("failed to parse block data for block %d: %w", blockNum, err)
 	}
@@ -208,7 +212,7 @@ func (bdm *BlockDataManager) processNewBlock(blockNum uint64) error {
 	bdm.BlockDataList = append(bdm.BlockDataList, data)
 	bdm.blockDataMap[blockNum] = true
 	if int(bdm.latestBlock)%10 == 0 { // Print every 10 blocks (1min)
-		log.Logger.Infof("Save block data from %s, current block num %d", bdm.chainClient.CessClient.GetCurrentRpcAddr(), bdm.latestBlock)
+		log.Logger.Infof("Save block data from %s, current block num %d", GlobalChainQuery.RpcAddr(), bdm.latestBlock)
 	}
 
 	log.Logger.Debugf("Added new block %d to queue, queue size now: %d", blockNum, len(bdm.BlockDataList))
@@ -243,8 +247,8 @@ func (bdm *BlockDataManager) GetQueueStatus() (int, uint64, uint64) {
 }
 
 func InitWatchdogClients(conf model.YamlConfig) error {
-	// Initialize the global block data manager first
-	InitBlockDataManager(conf.ScrapeInterval)
+	// Initialize the global block data manager first, it keeps the blocks between two chain queries of a miner
+	InitBlockDataManager(conf.Schedules.Chain * (100 + scheduleJitter(conf.Schedules)) / 100)
 
 	hosts := conf.Hosts
 	Clients = make(map[string]*WatchdogClient, len(hosts))
@@ -264,16 +268,16 @@ func InitWatchdogClients(conf model.YamlConfig) error {
 			}
 
 			httpClient := util.NewHTTPClient()
-			chainClient := util.NewCessChainClient([]string{constant.LocalRpcUrl, constant.DefaultRpcUrl})
 
 			Clients[host.IP] = &WatchdogClient{
-				Host:            host.IP,
-				Client:          dockerClient,
-				HTTPClient:      httpClient,
-				CessChainClient: chainClient,
-				MinerInfoMap:    make(map[string]*MinerInfo),
-				Updating:        false,
-				Active:          true,
+				Host:         host.IP,
+				Client:       dockerClient,
+				HTTPClient:   httpClient,
+				MinerInfoMap: make(map[string]*MinerInfo),
+				Components:   make(map[string]*ComponentInfo),
+				Selectors:    hostSelectors(host, conf),
+				ChainRpcs:    host.ChainRpcs,
+				Active:       true,
 			}
 			log.Logger.Infof("Create a docker client with host: %s successfully", host.IP)
 		}(host)
@@ -301,110 +305,211 @@ func RunWatchdogClients(conf model.YamlConfig) error {
 	return nil
 }
 
+// RunWatchdogClient discovers the containers of the host and schedules the tasks of the host until it is stopped
 func (cli *WatchdogClient) RunWatchdogClient(conf model.YamlConfig) {
-	for cli.Active {
-		log.Logger.Info("Start to run watchdog client")
-		if err := cli.start(conf); err != nil {
-			log.Logger.Warnf("Error when start %s watchdog client %v", cli.Host, err)
-		}
-		time.Sleep(time.Duration(CustomConfig.ScrapeInterval) * time.Second) // Scrape interval
+	ctx, cancel := context.WithCancel(context.Background())
+	cli.mutex.Lock()
+	cli.cancel = cancel
+	cli.mutex.Unlock()
+	if !cli.Active {
+		cancel()
+		return
 	}
-}
 
-func (cli *WatchdogClient) start(conf model.YamlConfig) error {
 	// Make sure each client does not start at the same time to prevent from being overloaded
-	SleepAFewSeconds()
-	cli.Updating = true
-	defer func() { cli.Updating = false }()
-	ctx := context.Background()
-	containers, err := cli.Client.ListContainers(ctx, cli.Host)
+	startDelay := func() time.Duration {
+		return time.Duration(rand.Int63n(int64(constant.ScheduleStartDelay * time.Second)))
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(startDelay()):
+	}
+	log.Logger.Infof("Start to run watchdog client of host %s", cli.Host)
+	// the other tasks depend on the miners found by the first discovery
+	cli.discover(ctx)
+
+	seconds := func(interval int) time.Duration { return time.Duration(interval) * time.Second }
+	schedules := conf.Schedules
+	GlobalScheduler.Schedule(ctx, cli.Host, constant.TaskDiscovery, seconds(schedules.Discovery),
+		util.Jitter(seconds(schedules.Discovery), scheduleJitter(schedules), rand.Float64()), cli.discover)
+	GlobalScheduler.Schedule(ctx, cli.Host, constant.TaskStats, seconds(schedules.Stats), startDelay(), cli.collectStats)
+	GlobalScheduler.Schedule(ctx, cli.Host, constant.TaskProbe, seconds(schedules.Probe), startDelay(), cli.probe)
+	GlobalScheduler.Schedule(ctx, cli.Host, constant.TaskChain, seconds(schedules.Chain), startDelay(), cli.queryChain)
+}
+
+// Stop stops the scheduled tasks of the client, a running task finishes its current run
+func (cli *WatchdogClient) Stop() {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	cli.Active = false
+	if cli.cancel != nil {
+		cli.cancel()
+	}
+}
+
+// IsUpdating reports whether a task of the client is running
+func (cli *WatchdogClient) IsUpdating() bool {
+	return GlobalScheduler != nil && GlobalScheduler.Running(cli.Host)
+}
+
+// miners returns the monitored miners of the host
+func (cli *WatchdogClient) miners() []*MinerInfo {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	miners := make([]*MinerInfo, 0, len(cli.MinerInfoMap))
+	for _, miner := range cli.MinerInfoMap {
+		miners = append(miners, miner)
+	}
+	return miners
+}
 
+// discover lists the containers of the host, registers the selected miners and components and removes the stopped ones
+func (cli *WatchdogClient) discover(ctx context.Context) {
+	containers, err := cli.Client.ListContainers(ctx, cli.Host)
 	if err != nil {
 		log.Logger.Errorf("Error when listing %s containers: %v", cli.Host, err)
-		return err
+		cli.recordHostUptime(false)
+		return
 	}
+	cli.recordHostUptime(true)
 
-	errChan := make(chan error, len(containers))
-	done := make(chan struct{})
-	go func() {
-		for err := range errChan {
-			log.Logger.Errorf("Error when %s task run: %v", cli.Host, err)
-		}
-		close(done)
-	}()
-
-	// Get miner info and miner config
-	var setContainersDataWG sync.WaitGroup
+	var miners, components []model.Container
+	componentOf := make(map[string]string)
+	running := make(map[string]bool, len(containers))
 	for _, container := range containers {
-		if !strings.Contains(container.Image, constant.MinerImage) {
+		running[container.ID] = true
+		component := cli.selectComponent(container)
+		if component == "" {
 			continue
 		}
-		runningMiners := make(map[string]bool)
-		for _, v := range containers {
-			runningMiners[v.ID] = true
+		if component != constant.ComponentMiner {
+			components = append(components, container)
+			componentOf[container.ID] = component
+			continue
 		}
+		miners = append(miners, container)
+	}
 
-		// clean miner if it is not running
-		for key, value := range cli.MinerInfoMap {
-			if !runningMiners[value.CInfo.ID] {
-				log.Logger.Infof("Miner %s on host: %v has been stopped or removed, delete it from current task", key, cli.Host)
-				delete(cli.MinerInfoMap, key)
-			}
+	// clean miner if it is not running
+	cli.mutex.Lock()
+	for key, value := range cli.MinerInfoMap {
+		if !running[value.CInfo.ID] {
+			log.Logger.Infof("Miner %s on host: %v has been stopped or removed, delete it from current task", key, cli.Host)
+			delete(cli.MinerInfoMap, key)
 		}
+	}
+	cli.mutex.Unlock()
+
+	// Get miner info and miner config
+	var setContainersDataWG sync.WaitGroup
+	for _, container := range miners {
 		setContainersDataWG.Add(1)
 		go func(container model.Container) {
 			defer setContainersDataWG.Done()
 			// send alert by webhook when parse config file failed
 			if err := cli.setMinerInfoMapItem(ctx, container, cli.Host); err != nil {
-				errChan <- err
+				log.Logger.Errorf("Error when %s task run: %v", cli.Host, err)
 			}
 		}(container)
 	}
 	setContainersDataWG.Wait()
 
-	// Set miners' container stats
+	var imageWG sync.WaitGroup
+	for _, miner := range cli.miners() {
+		imageWG.Add(1)
+		go func(m *MinerInfo) {
+			defer imageWG.Done()
+			cli.checkImageVersion(ctx, m)
+		}(miner)
+	}
+	imageWG.Wait()
+
+	cli.syncComponents(components, componentOf)
+}
+
+// collectStats sets the resource stats of the miners and the other components
+func (cli *WatchdogClient) collectStats(ctx context.Context) {
 	var setContainersStatsDataWG sync.WaitGroup
-	for _, miner := range cli.MinerInfoMap {
+	for _, miner := range cli.miners() {
 		setContainersStatsDataWG.Add(1)
 		go func(m *MinerInfo) {
 			defer setContainersStatsDataWG.Done()
 			// send alert by webhook when get container stats failed
 			if res, err := cli.SetContainerStats(ctx, m.CInfo.ID, cli.Host); err != nil {
-				errChan <- err
+				log.Logger.Errorf("Error when %s task run: %v", cli.Host, err)
 			} else {
+				cli.mutex.Lock()
 				m.CInfo.CPUPercent = res.CPUPercent
 				m.CInfo.MemoryPercent = res.MemoryPercent
 				m.CInfo.MemoryUsage = res.MemoryUsage
+				util.SetMetricRates(&res.Metrics, m.CInfo.Metrics)
+				m.CInfo.Metrics = &res.Metrics
+				cli.mutex.Unlock()
 			}
 		}(miner)
 	}
 	setContainersStatsDataWG.Wait()
+	cli.collectComponentStats(ctx)
+}
 
-	// Set miner's info on chain
-	for _, miner := range cli.MinerInfoMap {
-		SleepAFewSeconds()
-		// send alert by webhook and email when storage node get punishment
-		if minerStat, err := cli.SetChainData(miner.SignatureAcc, miner.CInfo.Created); err != nil {
-			errChan <- err
-		} else {
-			if _, exists := cli.MinerInfoMap[miner.SignatureAcc]; exists {
-				cli.MinerInfoMap[miner.SignatureAcc].MinerStat = minerStat
-			} else {
-				log.Logger.Error("Miner name does not match with conf file, please check your mineradm config file")
-			}
-		}
+// probe probes the endpoints which miners depend on from the host, the ports of the components and the chain nodes,
+// and checks the miner workspaces
+func (cli *WatchdogClient) probe(ctx context.Context) {
+	reference := referenceBlock(ctx)
+	var probeWG sync.WaitGroup
+	for _, miner := range cli.miners() {
+		probeWG.Add(1)
+		go func(m *MinerInfo) {
+			defer probeWG.Done()
+			cli.probeTees(ctx, m)
+			cli.probeMinerService(ctx, m)
+			cli.probeMinerRpcs(m, reference)
+			cli.checkWorkspace(ctx, m)
+		}(miner)
 	}
-
-	close(errChan)
-	<-done
-	return nil
+	probeWG.Wait()
+	cli.probeComponents(ctx)
+	cli.monitorChainNodes(ctx, reference)
 }
 
-func SleepAFewSeconds() {
-	source := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(source)
-	sleepDuration := r.Intn(10) + 1
-	time.Sleep(time.Duration(sleepDuration) * time.Second)
+// queryChain sets the miners' info on chain, the miners of the host are queried in one batch at the same block
+func (cli *WatchdogClient) queryChain(ctx context.Context) {
+	var miners []*MinerInfo
+	var publicKeys [][]byte
+	for _, miner := range cli.miners() {
+		publicKey, err := utils.ParsingPublickey(miner.SignatureAcc)
+		if err != nil {
+			log.Logger.Errorf("Error when %s task run: error occurred when parse public key of %s: %v", cli.Host, miner.SignatureAcc, err)
+			continue
+		}
+		miners = append(miners, miner)
+		publicKeys = append(publicKeys, publicKey)
+	}
+	if len(miners) == 0 {
+		cli.recordMinerUptime()
+		return
+	}
+	block, data, err := GlobalChainQuery.QueryMiners(ctx, publicKeys)
+	if err != nil {
+		log.Logger.Errorf("Error when %s task run: failed to query the miners from chain: %v", cli.Host, err)
+		return
+	}
+	for i, miner := range miners {
+		if ctx.Err() != nil {
+			return
+		}
+		// send alert by webhook and email when storage node get punishment
+		minerStat, err := cli.SetChainData(ctx, miner.SignatureAcc, publicKeys[i], miner.CInfo.Created, miner.Conf.Chain, block, data[i])
+		if err != nil {
+			log.Logger.Errorf("Error when %s task run: %v", cli.Host, err)
+			continue
+		}
+		cli.mutex.Lock()
+		miner.MinerStat = minerStat
+		cli.mutex.Unlock()
+	}
+	cli.recordMinerUptime()
 }
 
 // GetBlockDataList for WatchdogClient now uses the global block data manager
@@ -413,9 +518,16 @@ func (cli *WatchdogClient) GetBlockDataList() []chain.BlockData {
 }
 
 func (cli *WatchdogClient) setMinerInfoMapItem(ctx context.Context, cinfo model.Container, hostIp string) error {
-	if _, ok := cli.MinerInfoMap[cinfo.Name]; ok {
-		return nil
+	cli.mutex.Lock()
+	for _, miner := range cli.MinerInfoMap {
+		if miner.CInfo.ID == cinfo.ID {
+			// a known container, refresh its state and keep the collected data
+			refreshContainer(&miner.CInfo, cinfo)
+			cli.mutex.Unlock()
+			return nil
+		}
 	}
+	cli.mutex.Unlock()
 
 	res, err := cli.ExeCommand(ctx, cinfo.ID, exeConf, cli.Host)
 	if err != nil {
@@ -437,11 +549,11 @@ func (cli *WatchdogClient) setMinerInfoMapItem(ctx context.Context, cinfo model.
 
 	conf, err := util.ParseMinerConfigFile(res[8:]) // Skip header bytes (0-7)
 	if err != nil {
-		SleepAFewSeconds() // avoid webhook/smtp server api request limit
 		log.Logger.Errorf("Failed to parse storage node config file for container %s: %v on host: %s", cinfo.ID, err, cli.Host)
-		go doAlert(hostIp, fmt.Sprintf("Failed to parse storage node config file for container %s: %v on host: %s", cinfo.ID, err, cli.Host), "", cinfo.ID, GlobalBlockDataManager.latestBlock)
+		go doAlert(hostIp, constant.AlertKindMinerConfig, fmt.Sprintf("Failed to parse storage node config file for container %s: %v on host: %s", cinfo.ID, err, cli.Host), "", cinfo.ID, GlobalBlockDataManager.latestBlock)
 		return err
 	}
+	go resolveAlert(hostIp, constant.AlertKindMinerConfig, "", cinfo.ID)
 
 	key, err := signature.KeyringPairFromSecret(conf.Chain.Mnemonic, 0)
 	if err != nil {
@@ -458,44 +570,27 @@ func (cli *WatchdogClient) setMinerInfoMapItem(ctx context.Context, cinfo model.
 	cli.mutex.Lock()
 	defer cli.mutex.Unlock()
 
+	var stat model.MinerStat
+	if prev, ok := cli.MinerInfoMap[acc]; ok {
+		// the container has been recreated, keep the chain data until the next chain query
+		stat = prev.MinerStat
+	}
 	cli.MinerInfoMap[acc] = &MinerInfo{
 		SignatureAcc: acc,
 		CInfo:        cinfo,
 		Conf:         conf,
-		MinerStat:    model.MinerStat{},
+		MinerStat:    stat,
 	}
 
 	return nil
 }
 
-func doAlert(hostIP string, message string, signatureAcc string, containerID string, blockNumber uint64) {
-	if !CustomConfig.Alert.Enable {
-		return
-	}
-	content := model.AlertContent{
-		AlertTime:    time.Now().Format(constant.TimeFormat),
-		HostIp:       hostIP,
-		Description:  message,
-		SignatureAcc: signatureAcc,
-		ContainerID:  containerID,
-		BlockNumber:  blockNumber,
-	}
-	if WebhooksConfig != nil {
-		go func() {
-			if err := WebhooksConfig.SendAlertToWebhook(content); err != nil {
-				log.Logger.Error("Failed to send alert webhook:", err)
-			} else {
-				log.Logger.Infof("Webhook alert sent successfully: %v", content)
-			}
-		}()
-	}
-	if SmtpConfig != nil {
-		go func() {
-			if err := SmtpConfig.SendMail(content); err != nil {
-				log.Logger.Error("Failed to send alert email:", err)
-			} else {
-				log.Logger.Info("Email alert sent successfully")
-			}
-		}()
-	}
+// refreshContainer updates the state of a known container, the id and the collected stats are kept
+func refreshContainer(c *model.Container, cinfo model.Container) {
+	c.Names = cinfo.Names
+	c.Name = cinfo.Name
+	c.State = cinfo.State
+	c.Status = cinfo.Status
+	c.Labels = cinfo.Labels
+	c.Ports = cinfo.Ports
 }
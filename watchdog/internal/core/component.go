@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ComponentInfo is a monitored container of a CESS component other than the storage node, e.g. a chain node or a tee worker
+type ComponentInfo struct {
+	Host      string
+	Component string
+	CInfo     model.Container
+	Ports     []model.EndpointProbe // reachability of the published tcp ports
+}
+
+// componentCollector collects the component specific data of a container on each probe
+type componentCollector func(cli *WatchdogClient, ctx context.Context, component *ComponentInfo)
+
+var componentCollectors = map[string]componentCollector{
+	constant.ComponentChain: (*WatchdogClient).probeComponentPorts,
+	constant.ComponentTee:   (*WatchdogClient).probeComponentPorts,
+}
+
+var defaultSelector = model.ContainerSelector{Component: constant.ComponentMiner, Image: "*" + constant.MinerImage + "*"}
+
+// hostSelectors compiles the selectors of a host, the global selectors or the default miner selector. The invalid
+// selectors and the ones matching every container are skipped, the default selector is used if none is left
+func hostSelectors(host model.HostItem, conf model.YamlConfig) []*util.Selector {
+	where, selectors := "host "+host.IP, host.Selectors
+	if len(selectors) == 0 {
+		where, selectors = "config", conf.Selectors
+	}
+	var res []*util.Selector
+	for i, selector := range selectors {
+		compiled, err := util.CompileSelector(selector)
+		if err != nil {
+			log.Logger.Warnf("Container selector %d of %s will be ignored: %v", i, where, err)
+			continue
+		}
+		if c := selector.Component; c != "" && c != constant.ComponentMiner && componentCollectors[c] == nil {
+			log.Logger.Warnf("Container selector %d of %s selects unknown component %s, only container stats will be collected", i, where, c)
+		}
+		res = append(res, compiled)
+	}
+	if len(res) == 0 {
+		if len(selectors) > 0 {
+			log.Logger.Warnf("No valid container selector of %s, select the storage nodes by image %s", where, defaultSelector.Image)
+		}
+		compiled, _ := util.CompileSelector(defaultSelector)
+		res = append(res, compiled)
+	}
+	return res
+}
+
+// selectComponent returns the component of a container selected by the first matched selector, empty if not selected
+func (cli *WatchdogClient) selectComponent(container model.Container) string {
+	for _, selector := range cli.Selectors {
+		if selector.Match(container.Image, container.Name, container.Labels) {
+			if selector.Component == "" {
+				return constant.ComponentMiner
+			}
+			return selector.Component
+		}
+	}
+	return ""
+}
+
+// syncComponents registers the selected components of the host and removes the stopped ones
+func (cli *WatchdogClient) syncComponents(containers []model.Container, componentOf map[string]string) {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	running := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		running[container.ID] = true
+		if c, ok := cli.Components[container.ID]; ok {
+			// keep the collected data, refresh the container info
+			refreshContainer(&c.CInfo, container)
+		} else {
+			cli.Components[container.ID] = &ComponentInfo{Host: cli.Host, Component: componentOf[container.ID], CInfo: container}
+		}
+	}
+	for id, c := range cli.Components {
+		if !running[id] {
+			log.Logger.Infof("Component %s %s on host: %v has been stopped or removed, delete it from current task", c.Component, c.CInfo.Name, cli.Host)
+			delete(cli.Components, id)
+		}
+	}
+}
+
+// components returns the monitored components of the host
+func (cli *WatchdogClient) components() []*ComponentInfo {
+	cli.mutex.Lock()
+	defer cli.mutex.Unlock()
+	components := make([]*ComponentInfo, 0, len(cli.Components))
+	for _, c := range cli.Components {
+		components = append(components, c)
+	}
+	return components
+}
+
+// collectComponentStats sets the resource stats of the components
+func (cli *WatchdogClient) collectComponentStats(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, c := range cli.components() {
+		wg.Add(1)
+		go func(c *ComponentInfo) {
+			defer wg.Done()
+			if res, err := cli.SetContainerStats(ctx, c.CInfo.ID, cli.Host); err != nil {
+				log.Logger.Errorf("Error when %s task run: %v", cli.Host, err)
+			} else {
+				cli.mutex.Lock()
+				c.CInfo.CPUPercent = res.CPUPercent
+				c.CInfo.MemoryPercent = res.MemoryPercent
+				c.CInfo.MemoryUsage = res.MemoryUsage
+				util.SetMetricRates(&res.Metrics, c.CInfo.Metrics)
+				c.CInfo.Metrics = &res.Metrics
+				cli.mutex.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+}
+
+// probeComponents collects the component specific data of the components
+func (cli *WatchdogClient) probeComponents(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, c := range cli.components() {
+		collect, ok := componentCollectors[c.Component]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(c *ComponentInfo) {
+			defer wg.Done()
+			collect(cli, ctx, c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// probeComponentPorts probes the published tcp ports of a component from watchdog, and alerts if none of them is reachable
+func (cli *WatchdogClient) probeComponentPorts(ctx context.Context, component *ComponentInfo) {
+	cli.mutex.Lock()
+	endpoints := make([]string, len(component.CInfo.Ports))
+	for i, port := range component.CInfo.Ports {
+		endpoints[i] = net.JoinHostPort(cli.Host, strconv.Itoa(port))
+	}
+	prev := component.Ports
+	cli.mutex.Unlock()
+	if len(endpoints) == 0 {
+		return
+	}
+	probes := cli.probeEndpoints(ctx, component.CInfo.ID, endpoints, prev, false)
+	cli.mutex.Lock()
+	component.Ports = probes
+	cli.mutex.Unlock()
+
+	var errs []string
+	for _, probe := range probes {
+		if probe.Failures < constant.ProbeFailureThreshold {
+			go resolveAlert(cli.Host, constant.AlertKindComponent, "", component.CInfo.ID)
+			return
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", probe.Endpoint, probe.LastError))
+	}
+	go doAlert(cli.Host, constant.AlertKindComponent, fmt.Sprintf("Host: %s, None of the published ports of %s %s is reachable: %s",
+		cli.Host, component.Component, component.CInfo.Name, strings.Join(errs, "; ")), "", component.CInfo.ID, GlobalBlockDataManager.latestBlock)
+}
+
+// ComponentList returns the monitored components of the hosts, host is an optional filter
+func ComponentList(host string) []ComponentInfo {
+	res := make([]ComponentInfo, 0)
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		for _, c := range cli.Components {
+			res = append(res, *c)
+		}
+		cli.mutex.Unlock()
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		if res[i].Component != res[j].Component {
+			return res[i].Component < res[j].Component
+		}
+		return res[i].CInfo.Name < res[j].CInfo.Name
+	})
+	return res
+}
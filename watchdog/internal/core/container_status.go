@@ -3,18 +3,20 @@ package core
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/log"
 	"github.com/CESSProject/watchdog/internal/model"
 	"github.com/CESSProject/watchdog/internal/util"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/pkg/errors"
 	"io"
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type DockerCli interface {
@@ -26,6 +28,8 @@ type DockerCli interface {
 	Ping(ctx context.Context) (types.Ping, error)
 	ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error)
 	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
 }
 
 type Client struct {
@@ -68,9 +72,10 @@ func NewClient(host model.HostItem) (*Client, error) {
 func (cli *Client) ListContainers(ctx context.Context, host string) ([]model.Container, error) {
 	list, err := cli.dockerCli.ContainerList(ctx, types.ContainerListOptions{})
 	if err != nil {
-		go doAlert(host, "Failed to call list container api from docker daemon", "", "", GlobalBlockDataManager.latestBlock)
+		go doAlert(host, constant.AlertKindDockerList, "Failed to call list container api from docker daemon", "", "", GlobalBlockDataManager.latestBlock)
 		return nil, err
 	}
+	go resolveAlert(host, constant.AlertKindDockerList, "", "")
 	containers := make([]model.Container, len(list))
 	for i, c := range list {
 		name := "no name"
@@ -90,74 +95,85 @@ func (cli *Client) ListContainers(ctx context.Context, host string) ([]model.Con
 			CPUPercent:    "0%",
 			MemoryPercent: "0%",
 			MemoryUsage:   "0",
+			Labels:        c.Labels,
+		}
+		for _, port := range c.Ports {
+			if port.Type == "tcp" && port.PublicPort > 0 && !containsPort(containers[i].Ports, int(port.PublicPort)) {
+				containers[i].Ports = append(containers[i].Ports, int(port.PublicPort))
+			}
 		}
 	}
 	return containers, nil
 }
 
+// containsPort dedups the ports published on both ipv4 and ipv6
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectImage inspects an image by id or reference on the host
+func (cli *Client) InspectImage(ctx context.Context, image string) (types.ImageInspect, error) {
+	inspect, _, err := cli.dockerCli.ImageInspectWithRaw(ctx, image)
+	return inspect, err
+}
+
 func (cli *Client) SetContainerStats(ctx context.Context, cid string, host string) (model.ContainerStat, error) {
 	response, err := cli.dockerCli.ContainerStats(ctx, cid, false)
 	if err != nil {
 		log.Logger.Errorf("Failed to get container stats: %v", err)
-		go doAlert(host, "Failed to call container stats api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
-		return model.ContainerStat{}, nil
+		go doAlert(host, constant.AlertKindDockerStats, "Failed to call container stats api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
+		return model.ContainerStat{}, errors.Wrap(err, "get container stats error")
 	}
+	go resolveAlert(host, constant.AlertKindDockerStats, "", cid)
 	defer func(Body io.ReadCloser) {
 		err = Body.Close()
 		if err != nil {
 			log.Logger.Errorf("Failed to close io reader: %v", err)
 		}
 	}(response.Body)
-	var v *types.StatsJSON
-	decoder := json.NewDecoder(response.Body)
-
-	if err = decoder.Decode(&v); err == io.EOF {
-		log.Logger.Error("Docker Container Stats API Response io.EOF")
-		return model.ContainerStat{}, nil
-	} else if err != nil {
-		panic(err)
+	v, err := util.DecodeContainerStats(response.Body)
+	if err != nil {
+		log.Logger.Errorf("Failed to decode stats of container %s: %v", cid, err)
+		return model.ContainerStat{}, errors.Wrapf(err, "decode stats of container %s error", cid)
+	}
+	metrics := util.StatsMetrics(v)
+	metrics.UpdatedAt = time.Now().Unix()
+	if inspect, err := cli.dockerCli.ContainerInspect(ctx, cid); err != nil {
+		log.Logger.Warnf("Failed to inspect container %s: %v", cid, err)
+	} else if inspect.ContainerJSONBase != nil {
+		metrics.RestartCount = inspect.RestartCount
+		if inspect.State != nil {
+			metrics.OOMKilled = inspect.State.OOMKilled
+			metrics.StartedAt = inspect.State.StartedAt
+		}
 	}
-	cpuPercent := calculateCPUPercentUnix(v)
-	// Usage must subtract cache-file-used
-	memUsage := v.MemoryStats.Usage - v.MemoryStats.Stats["file"]
-	memLimit := v.MemoryStats.Limit
-	memPercent := float64(memUsage) / float64(memLimit) * 100.0
 	res := model.ContainerStat{
-		CPUPercent:    cpuPercent,
-		MemoryPercent: strconv.FormatFloat(memPercent, 'f', 2, 64),
-		MemoryUsage:   strconv.Itoa(int(memUsage / 1048576)),
+		CPUPercent:    strconv.FormatFloat(metrics.CPUPercent, 'f', 2, 64),
+		MemoryPercent: strconv.FormatFloat(metrics.MemoryPercent, 'f', 2, 64),
+		MemoryUsage:   strconv.Itoa(int(metrics.MemoryUsage / 1048576)),
+		Metrics:       metrics,
 	}
 	return res, nil
 }
 
-func calculateCPUPercentUnix(v *types.StatsJSON) string {
-
-	cpuDelta := float64(v.CPUStats.CPUUsage.TotalUsage) - float64(v.PreCPUStats.CPUUsage.TotalUsage)
-	systemDelta := float64(v.CPUStats.SystemUsage) - float64(v.PreCPUStats.SystemUsage)
-	onlineCPUs := float64(v.CPUStats.OnlineCPUs)
-
-	if onlineCPUs == 0.0 {
-		onlineCPUs = float64(len(v.CPUStats.CPUUsage.PercpuUsage))
-	}
-	if systemDelta > 0.0 && cpuDelta > 0.0 {
-		cpuPercent := (cpuDelta / systemDelta) * onlineCPUs * 100.0
-		return strconv.FormatFloat(cpuPercent, 'f', 2, 64)
-	}
-	return "0.00"
-}
-
 func (cli *Client) ExeCommand(ctx context.Context, cid string, config types.ExecConfig, host string) ([]byte, error) {
 	execId, err := cli.dockerCli.ContainerExecCreate(ctx, cid, config)
 	if err != nil {
-		go doAlert(host, "Failed to call ContainerExecCreate api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
+		go doAlert(host, constant.AlertKindDockerExec, "Failed to call ContainerExecCreate api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
 		return nil, errors.Wrap(err, "exe cmd in container error")
 	}
 	resp, err := cli.dockerCli.ContainerExecAttach(ctx, execId.ID, types.ExecStartCheck{})
 	if err != nil {
-		go doAlert(host, "Failed to call ContainerExecAttach api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
+		go doAlert(host, constant.AlertKindDockerExec, "Failed to call ContainerExecAttach api from docker daemon", "", cid, GlobalBlockDataManager.latestBlock)
 		return nil, errors.Wrap(err, "exe cmd in container error")
 	}
 	defer resp.Close()
+	go resolveAlert(host, constant.AlertKindDockerExec, "", cid)
 
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, resp.Reader); err != nil {
@@ -167,6 +183,40 @@ func (cli *Client) ExeCommand(ctx context.Context, cid string, config types.Exec
 	return buf.Bytes(), nil
 }
 
+// ExecProbe runs a check command in the container and returns its exit code and stdout,
+// failures of the docker api are returned as error but do not alert
+func (cli *Client) ExecProbe(ctx context.Context, cid string, cmd []string) (int, string, error) {
+	execId, err := cli.dockerCli.ContainerExecCreate(ctx, cid, types.ExecConfig{Cmd: cmd, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return 0, "", errors.Wrap(err, "create exec in container error")
+	}
+	resp, err := cli.dockerCli.ContainerExecAttach(ctx, execId.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, "", errors.Wrap(err, "attach exec in container error")
+	}
+	var stdout, stderr bytes.Buffer
+	_, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
+	resp.Close()
+	if err != nil {
+		return 0, "", errors.Wrap(err, "read response from container error")
+	}
+	for i := 0; i < 10; i++ {
+		inspect, err := cli.dockerCli.ContainerExecInspect(ctx, execId.ID)
+		if err != nil {
+			return 0, "", errors.Wrap(err, "inspect exec in container error")
+		}
+		if !inspect.Running {
+			output := strings.TrimSpace(stdout.String())
+			if inspect.ExitCode != 0 && stderr.Len() > 0 {
+				output = strings.TrimSpace(stderr.String())
+			}
+			return inspect.ExitCode, output, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return 0, "", errors.New("exec in container does not exit")
+}
+
 func (cli *Client) Ping(ctx context.Context) (types.Ping, error) {
 	return cli.dockerCli.Ping(ctx)
 }
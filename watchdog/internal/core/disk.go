@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"strings"
+	"time"
+)
+
+// dfScript prints the block and inode usage of the filesystem holding $0
+const dfScript = `df -P -k "$0" && df -P -i "$0"`
+
+type diskSample struct {
+	time int64
+	used uint64
+}
+
+// checkWorkspace measures the filesystem usage of a miner workspace by df in the container,
+// and alerts if the filesystem is (projected to be) full
+func (cli *WatchdogClient) checkWorkspace(ctx context.Context, miner *MinerInfo) {
+	cli.mutex.Lock()
+	workspace := miner.Conf.App.Workspace
+	maxUseSpace := uint64(miner.Conf.App.MaxUseSpace) * constant.Size1gib
+	cli.mutex.Unlock()
+	if workspace == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	exitCode, output, err := cli.Client.ExecProbe(ctx, miner.CInfo.ID, []string{"sh", "-c", dfScript, workspace})
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("df exit with code %d: %s", exitCode, output)
+	}
+	var usage model.DiskUsage
+	if err == nil {
+		usage, err = util.ParseDfOutput(output)
+	}
+	if err != nil {
+		log.Logger.Warnf("%s failed to get workspace usage of %s: %v", cli.Host, miner.CInfo.Name, err)
+		return
+	}
+	now := time.Now()
+	usage.Path = workspace
+	usage.MaxUseSpace = maxUseSpace
+	usage.UpdatedAt = now.Unix()
+
+	cli.mutex.Lock()
+	samples := append(miner.diskSamples, diskSample{time: now.Unix(), used: usage.Used})
+	for len(samples) > 0 && now.Unix()-samples[0].time > constant.DiskGrowthWindow {
+		samples = samples[1:]
+	}
+	miner.diskSamples = samples
+	cli.mutex.Unlock()
+
+	if len(samples) >= 2 {
+		xs := make([]float64, len(samples))
+		ys := make([]float64, len(samples))
+		for i, s := range samples {
+			xs[i] = float64(s.time-now.Unix()) / (24 * 3600)
+			ys[i] = float64(s.used)
+		}
+		usage.GrowthRate, _, _ = util.LinearRegression(xs, ys)
+		if usage.GrowthRate > 0 {
+			usage.FullAt = projectTime(now.Unix(), float64(usage.Available)/usage.GrowthRate)
+		}
+	}
+	cli.mutex.Lock()
+	miner.Disk = &usage
+	cli.mutex.Unlock()
+
+	thresholds := CustomConfig.Alert.Thresholds
+	var problems []string
+	if usage.UsedPercent >= float64(thresholds.DiskUsagePercent) {
+		problems = append(problems, fmt.Sprintf("%.1f%% of disk space used", usage.UsedPercent))
+	}
+	if usage.InodesTotal > 0 && usage.InodesUsedPercent >= float64(thresholds.InodeUsagePercent) {
+		problems = append(problems, fmt.Sprintf("%.1f%% of inodes used", usage.InodesUsedPercent))
+	}
+	if usage.FullAt > 0 && usage.FullAt-now.Unix() < int64(thresholds.DiskFullHours)*3600 {
+		problems = append(problems, fmt.Sprintf("projected to be full at %s", time.Unix(usage.FullAt, 0).Format(constant.TimeFormat)))
+	}
+	if maxUseSpace > usage.Total && usage.Total > 0 {
+		problems = append(problems, fmt.Sprintf("maxusespace %s exceeds the filesystem size %s",
+			util.FormatBytes(maxUseSpace), util.FormatBytes(usage.Total)))
+	}
+	if len(problems) > 0 {
+		go doAlert(cli.Host, constant.AlertKindDisk, fmt.Sprintf("Host: %s, The workspace %s of Storage Node %s (%s): %s", cli.Host, workspace,
+			miner.CInfo.Name, usage.Filesystem, strings.Join(problems, ", ")), miner.SignatureAcc, miner.CInfo.ID, GlobalBlockDataManager.latestBlock)
+	} else {
+		go resolveAlert(cli.Host, constant.AlertKindDisk, miner.SignatureAcc, miner.CInfo.ID)
+	}
+}
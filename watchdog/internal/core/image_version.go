@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkImageVersion resolves the tag and digest of the image a miner runs, and alerts if the image is outdated
+// or not the expected version
+func (cli *WatchdogClient) checkImageVersion(ctx context.Context, miner *MinerInfo) {
+	version := model.ImageVersion{ImageID: miner.CInfo.ImageID, UpdatedAt: time.Now().Unix()}
+	version.Repository, version.Tag, version.Digest = util.ParseImageRef(miner.CInfo.Image)
+	// docker lists the image id instead of the name if the tag has been moved to another image
+	untagged := strings.HasPrefix(miner.CInfo.Image, "sha256:")
+	if untagged {
+		version.Repository, version.Tag = "", ""
+	}
+	inspect, err := cli.Client.InspectImage(ctx, miner.CInfo.ImageID)
+	if err != nil {
+		log.Logger.Warnf("%s failed to inspect image %s of %s: %v", cli.Host, miner.CInfo.Image, miner.CInfo.Name, err)
+	}
+	for _, repoDigest := range inspect.RepoDigests {
+		repository, _, digest := util.ParseImageRef(repoDigest)
+		if version.Repository == "" {
+			version.Repository = repository
+		}
+		if repository == version.Repository && version.Digest == "" {
+			version.Digest = digest
+		}
+	}
+
+	conf := CustomConfig.Version
+	if untagged {
+		version.Outdated = true
+	} else if conf.CheckLocal && version.Tag != "" {
+		latest, err := cli.Client.InspectImage(ctx, version.Repository+":"+version.Tag)
+		if err != nil {
+			log.Logger.Warnf("%s failed to inspect image %s:%s: %v", cli.Host, version.Repository, version.Tag, err)
+		} else {
+			version.LatestID = latest.ID
+			version.Outdated = latest.ID != version.ImageID
+		}
+	}
+	if expected := conf.Expected; expected != "" {
+		if strings.HasPrefix(expected, "sha256:") {
+			version.Unexpected = expected != version.Digest && expected != version.ImageID
+		} else {
+			version.Unexpected = expected != version.Tag
+		}
+	}
+	cli.mutex.Lock()
+	miner.Image = &version
+	cli.mutex.Unlock()
+
+	var problems []string
+	if version.Outdated {
+		problems = append(problems, "the image on the host has been updated, the container needs to be recreated")
+	}
+	if version.Unexpected {
+		problems = append(problems, fmt.Sprintf("the expected version is %s", conf.Expected))
+	}
+	if len(problems) > 0 {
+		go doAlert(cli.Host, constant.AlertKindImage, fmt.Sprintf("Host: %s, Storage Node %s runs image %s (%s): %s", cli.Host, miner.CInfo.Name,
+			miner.CInfo.Image, shortDigest(version), strings.Join(problems, ", ")), miner.SignatureAcc, miner.CInfo.ID, GlobalBlockDataManager.latestBlock)
+	} else {
+		go resolveAlert(cli.Host, constant.AlertKindImage, miner.SignatureAcc, miner.CInfo.ID)
+	}
+}
+
+func shortDigest(version model.ImageVersion) string {
+	digest := version.Digest
+	if digest == "" {
+		digest = version.ImageID
+	}
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
+
+// FleetVersions groups the miners of the monitored hosts by the image they run, host is an optional filter
+func FleetVersions(host string) model.FleetVersions {
+	res := model.FleetVersions{Expected: CustomConfig.Version.Expected, Versions: make([]model.VersionGroup, 0)}
+	groups := make(map[string]*model.VersionGroup)
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		for acc, miner := range cli.MinerInfoMap {
+			if miner.Image == nil {
+				continue
+			}
+			version := *miner.Image
+			group, ok := groups[version.ImageID]
+			if !ok {
+				group = &model.VersionGroup{Repository: version.Repository, Tag: version.Tag, Digest: version.Digest, ImageID: version.ImageID}
+				groups[version.ImageID] = group
+			}
+			group.Count++
+			group.Miners = append(group.Miners, model.VersionMember{
+				Host:         hostIP,
+				Name:         miner.CInfo.Name,
+				SignatureAcc: acc,
+				Outdated:     version.Outdated,
+				Unexpected:   version.Unexpected,
+			})
+			res.Total++
+			if version.Outdated {
+				res.Outdated++
+			}
+			if version.Unexpected {
+				res.Unexpected++
+			}
+		}
+		cli.mutex.Unlock()
+	}
+	for _, group := range groups {
+		sort.Slice(group.Miners, func(i, j int) bool {
+			if group.Miners[i].Host != group.Miners[j].Host {
+				return group.Miners[i].Host < group.Miners[j].Host
+			}
+			return group.Miners[i].Name < group.Miners[j].Name
+		})
+		res.Versions = append(res.Versions, *group)
+	}
+	sort.Slice(res.Versions, func(i, j int) bool {
+		if res.Versions[i].Count != res.Versions[j].Count {
+			return res.Versions[i].Count > res.Versions[j].Count
+		}
+		return res.Versions[i].ImageID < res.Versions[j].ImageID
+	})
+	return res
+}
@@ -22,6 +22,8 @@ var CustomConfig model.YamlConfig
 
 var SmtpConfig *util.SmtpConfig
 var WebhooksConfig *util.WebhookConfig
+var PagerDutyConfig *util.PagerDutyConfig
+var OpsgenieConfig *util.OpsgenieConfig
 
 func Run() {
 	log.InitLogger()
@@ -31,6 +33,19 @@ func Run() {
 	}
 	InitSmtpConfig()
 	InitWebhookConfig()
+	InitIncidentConfig()
+	InitAlertChannels()
+	InitAlertHistory()
+	InitSilenceManager()
+	InitSpaceHistory()
+	InitBalanceHistory()
+	InitPunishmentHistory()
+	InitMinerEventHistory()
+	InitUptimeHistory()
+	InitAlertQueue()
+	InitReporter()
+	InitScheduler()
+	InitChainQueryService()
 	err = InitWatchdogClients(CustomConfig)
 	if err != nil {
 		log.Logger.Fatalf("Init CESS Node Monitor Service Failed: %v", err)
@@ -93,28 +108,122 @@ func InitWatchdogConfig() error {
 
 	// set default value for CustomConfig.Auth
 	CustomConfig = setDefaultValueForAuth(CustomConfig)
+	CustomConfig = setDefaultValueForDelivery(CustomConfig)
+	CustomConfig = setDefaultValueForThresholds(CustomConfig)
+	CustomConfig = setDefaultValueForUptime(CustomConfig)
+	alertRoutes = compileRoutes(CustomConfig.Alert.Routes)
+	validateEventRules(CustomConfig.Alert.EventRules)
+	maintenanceWindows = compileMaintenanceWindows(CustomConfig.Alert.MaintenanceWindows)
 
-	// 1800 <= ScrapeInterval <= 3600
-	CustomConfig.ScrapeInterval = int(math.Max(1800, math.Min(float64(CustomConfig.ScrapeInterval), 3600)))
+	CustomConfig = setDefaultValueForSchedules(CustomConfig)
+	CustomConfig = setDefaultValueForChainQuery(CustomConfig)
 	log.Logger.Infof("Init watchdog with config file:\n %v \n", CustomConfig)
 	return nil
 }
 
+func setDefaultValueForSchedules(conf model.YamlConfig) model.YamlConfig {
+	schedules := &conf.Schedules
+	bound := func(name string, interval int, def int, min int, max int) int {
+		if interval == 0 {
+			return def
+		}
+		if interval < min || interval > max {
+			log.Logger.Warnf("The %s interval %ds is out of [%d, %d], use the nearest bound", name, interval, min, max)
+			return int(math.Max(float64(min), math.Min(float64(interval), float64(max))))
+		}
+		return interval
+	}
+	if schedules.Chain == 0 {
+		// scrapeInterval is the chain interval of the earlier versions
+		schedules.Chain = conf.ScrapeInterval
+	}
+	schedules.Discovery = bound(constant.TaskDiscovery, schedules.Discovery, constant.DefaultDiscoveryInterval, constant.MinDiscoveryInterval, constant.MaxDiscoveryInterval)
+	schedules.Stats = bound(constant.TaskStats, schedules.Stats, constant.DefaultStatsInterval, constant.MinStatsInterval, constant.MaxStatsInterval)
+	schedules.Chain = bound(constant.TaskChain, schedules.Chain, constant.DefaultChainInterval, constant.MinChainInterval, constant.MaxChainInterval)
+	schedules.Probe = bound(constant.TaskProbe, schedules.Probe, constant.DefaultProbeInterval, constant.MinProbeInterval, constant.MaxProbeInterval)
+	if schedules.Jitter != nil && (*schedules.Jitter < 0 || *schedules.Jitter > constant.MaxScheduleJitter) {
+		log.Logger.Warnf("The schedule jitter %d%% is out of [0, %d], use the default %d%%", *schedules.Jitter, constant.MaxScheduleJitter, constant.DefaultScheduleJitter)
+		schedules.Jitter = nil
+	}
+	if schedules.Jitter == nil {
+		jitter := constant.DefaultScheduleJitter
+		schedules.Jitter = &jitter
+	}
+	conf.ScrapeInterval = schedules.Chain
+	return conf
+}
+
+func setDefaultValueForChainQuery(conf model.YamlConfig) model.YamlConfig {
+	query := &conf.ChainQuery
+	if query.Workers <= 0 || query.Workers > constant.MaxChainQueryWorkers {
+		if query.Workers != 0 {
+			log.Logger.Warnf("The chain query workers %d is out of [1, %d], use the default %d", query.Workers, constant.MaxChainQueryWorkers, constant.DefaultChainQueryWorkers)
+		}
+		query.Workers = constant.DefaultChainQueryWorkers
+	}
+	if query.QPS <= 0 || query.QPS > constant.MaxChainQueryQPS {
+		if query.QPS != 0 {
+			log.Logger.Warnf("The chain query qps %d is out of [1, %d], use the default %d", query.QPS, constant.MaxChainQueryQPS, constant.DefaultChainQueryQPS)
+		}
+		query.QPS = constant.DefaultChainQueryQPS
+	}
+	return conf
+}
+
+func setDefaultValueForUptime(conf model.YamlConfig) model.YamlConfig {
+	windows := make([]int, 0, len(conf.Uptime.Windows))
+	for _, days := range conf.Uptime.Windows {
+		if days > 0 && days*24*3600 <= constant.UptimeHistoryRetention {
+			windows = append(windows, days)
+		} else {
+			log.Logger.Warnf("Uptime window of %d days is ignored, should be between 1 and %d", days, constant.UptimeHistoryRetention/24/3600)
+		}
+	}
+	if len(windows) == 0 {
+		windows = []int{1, 7, 30}
+	}
+	conf.Uptime.Windows = windows
+	if conf.Uptime.Target <= 0 || conf.Uptime.Target > 100 {
+		conf.Uptime.Target = constant.DefaultUptimeTarget
+	}
+	return conf
+}
+
 func InitSmtpConfig() {
-	if CustomConfig.Alert.Email.SmtpEndpoint == "" ||
-		CustomConfig.Alert.Email.SmtpPort == 0 ||
-		CustomConfig.Alert.Email.SenderAddr == "" ||
-		CustomConfig.Alert.Email.SmtpPassword == "" ||
-		len(CustomConfig.Alert.Email.Receiver) == 0 {
+	SmtpConfig = nil
+	email := CustomConfig.Alert.Email
+	if email.SmtpEndpoint == "" || email.SmtpPort == 0 {
 		return
 	}
-	SmtpConfig = &util.SmtpConfig{
-		SmtpUrl:      CustomConfig.Alert.Email.SmtpEndpoint,
-		SmtpPort:     CustomConfig.Alert.Email.SmtpPort,
-		SenderAddr:   CustomConfig.Alert.Email.SenderAddr,
-		SmtpPassword: CustomConfig.Alert.Email.SmtpPassword,
-		Receiver:     CustomConfig.Alert.Email.Receiver,
+	conf := &util.SmtpConfig{
+		Host:     email.SmtpEndpoint,
+		Port:     email.SmtpPort,
+		TLSMode:  strings.ToLower(email.TLSMode),
+		Username: email.SenderAddr,
+		Password: email.SmtpPassword,
+		From:     email.From,
+		To:       email.Receiver,
+		Cc:       email.Cc,
+		Bcc:      email.Bcc,
+		Subject:  email.Subject,
+	}
+	if conf.TLSMode == "" {
+		conf.TLSMode = constant.SmtpTLSModeStartTLS
+		if conf.Port == constant.SmtpImplicitTLSPort {
+			conf.TLSMode = constant.SmtpTLSModeTLS
+		}
+	}
+	if conf.From == "" {
+		conf.From = email.SenderAddr
+	}
+	if conf.Subject == "" {
+		conf.Subject = constant.SmtpDefSubject
+	}
+	if err := util.ValidateSmtpConfig(conf); err != nil {
+		log.Logger.Errorf("Invalid email config, email alert is disabled: %v", err)
+		return
 	}
+	SmtpConfig = conf
 }
 
 func InitWebhookConfig() {
@@ -126,6 +235,23 @@ func InitWebhookConfig() {
 	}
 }
 
+func InitIncidentConfig() {
+	PagerDutyConfig = nil
+	OpsgenieConfig = nil
+	if CustomConfig.Alert.PagerDuty.RoutingKey != "" {
+		PagerDutyConfig = &util.PagerDutyConfig{
+			RoutingKey: CustomConfig.Alert.PagerDuty.RoutingKey,
+			EventsURL:  CustomConfig.Alert.PagerDuty.EventsURL,
+		}
+	}
+	if CustomConfig.Alert.Opsgenie.ApiKey != "" {
+		OpsgenieConfig = &util.OpsgenieConfig{
+			ApiKey: CustomConfig.Alert.Opsgenie.ApiKey,
+			ApiURL: CustomConfig.Alert.Opsgenie.ApiURL,
+		}
+	}
+}
+
 func setDefaultValueForAuth(cfg model.YamlConfig) model.YamlConfig {
 	if cfg.Auth.Username == "" {
 		cfg.Auth.Username = "cess"
@@ -146,3 +272,70 @@ func setDefaultValueForAuth(cfg model.YamlConfig) model.YamlConfig {
 
 	return cfg
 }
+
+func setDefaultValueForDelivery(cfg model.YamlConfig) model.YamlConfig {
+	if cfg.Alert.Delivery.MaxAttempts <= 0 {
+		cfg.Alert.Delivery.MaxAttempts = constant.DefaultAlertMaxAttempts
+	}
+	if cfg.Alert.Delivery.InitialBackoff <= 0 {
+		cfg.Alert.Delivery.InitialBackoff = constant.DefaultAlertInitialBackoff
+	}
+	if cfg.Alert.Delivery.MaxBackoff < cfg.Alert.Delivery.InitialBackoff {
+		cfg.Alert.Delivery.MaxBackoff = int(math.Max(constant.DefaultAlertMaxBackoff, float64(cfg.Alert.Delivery.InitialBackoff)))
+	}
+	if cfg.Alert.RepeatInterval <= 0 {
+		cfg.Alert.RepeatInterval = constant.DefaultAlertRepeatInterval
+	}
+	return cfg
+}
+
+func setDefaultValueForThresholds(cfg model.YamlConfig) model.YamlConfig {
+	thresholds := &cfg.Alert.Thresholds
+	if thresholds.CollateralPerTiB != "" {
+		if _, err := util.ParseTokenAmount(thresholds.CollateralPerTiB); err != nil {
+			log.Logger.Warnf("Invalid collateral_per_tib, collateral per TiB check is disabled: %v", err)
+			thresholds.CollateralPerTiB = ""
+		}
+	}
+	if thresholds.MinCollateral != "" {
+		if _, err := util.ParseTokenAmount(thresholds.MinCollateral); err != nil {
+			log.Logger.Warnf("Invalid min_collateral, minimum collateral check is disabled: %v", err)
+			thresholds.MinCollateral = ""
+		}
+	}
+	if thresholds.MinFeeBalance == "" {
+		thresholds.MinFeeBalance = constant.DefaultMinFeeBalance
+	}
+	if _, err := util.ParseTokenAmount(thresholds.MinFeeBalance); err != nil {
+		log.Logger.Warnf("Invalid min_fee_balance, use the default %s CESS: %v", constant.DefaultMinFeeBalance, err)
+		thresholds.MinFeeBalance = constant.DefaultMinFeeBalance
+	}
+	if thresholds.RpcMaxLag <= 0 {
+		thresholds.RpcMaxLag = constant.DefaultRpcMaxLag
+	}
+	if thresholds.ChainMaxLag <= 0 {
+		thresholds.ChainMaxLag = constant.DefaultChainMaxLag
+	}
+	if thresholds.ChainStallMinutes <= 0 {
+		thresholds.ChainStallMinutes = constant.DefaultChainStallMinutes
+	}
+	if thresholds.ChainFinalityLag <= 0 {
+		thresholds.ChainFinalityLag = constant.DefaultChainFinalityLag
+	}
+	if thresholds.DiskUsagePercent <= 0 {
+		thresholds.DiskUsagePercent = constant.DefaultDiskUsagePercent
+	}
+	if thresholds.InodeUsagePercent <= 0 {
+		thresholds.InodeUsagePercent = constant.DefaultInodeUsagePercent
+	}
+	if thresholds.DiskFullHours <= 0 {
+		thresholds.DiskFullHours = constant.DefaultDiskFullHours
+	}
+	if thresholds.ChallengeWarnBlocks <= 0 {
+		thresholds.ChallengeWarnBlocks = constant.DefaultChallengeWarnBlocks
+	}
+	if thresholds.RewardStallEras < 0 {
+		thresholds.RewardStallEras = 0
+	}
+	return cfg
+}
@@ -0,0 +1,397 @@
+package core
+
+import (
+	"fmt"
+	"github.com/CESSProject/cess-go-sdk/chain"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const minerEventStore = "miner_events"
+
+var minerEventKinds = []string{
+	constant.EventRegistration, constant.EventExit, constant.EventWithdraw, constant.EventIdleProof, constant.EventServiceProof,
+	constant.EventTagCertification, constant.EventRewardClaim, constant.EventCollateral, constant.EventFrozen, constant.EventUnfrozen,
+	constant.EventPunishment,
+}
+
+type minerEventData struct {
+	Events   map[string][]model.MinerEvent `json:"events"`   // key: signature account, ordered by block
+	Observed map[string]int64              `json:"observed"` // unix timestamp when watchdog started to observe the miner
+	Statuses map[string]string             `json:"statuses"` // the last known status on chain
+	// Transitions are the status transitions of each miner, ordered by time
+	Transitions map[string][]model.StatusTransition `json:"transitions"`
+}
+
+// MinerEventHistory keeps the chain events of the monitored miners found in the block queue,
+// it is flushed to the data directory periodically
+type MinerEventHistory struct {
+	mutex sync.RWMutex
+	data  minerEventData
+	dirty bool
+}
+
+var GlobalMinerEventHistory *MinerEventHistory
+
+func InitMinerEventHistory() {
+	if GlobalMinerEventHistory != nil {
+		return
+	}
+	GlobalMinerEventHistory = &MinerEventHistory{}
+	if err := store.Load(minerEventStore, &GlobalMinerEventHistory.data); err != nil {
+		log.Logger.Warnf("Failed to load miner events from %s: %v", constant.DataPath, err)
+	}
+	data := &GlobalMinerEventHistory.data
+	if data.Events == nil {
+		data.Events = make(map[string][]model.MinerEvent)
+	}
+	if data.Observed == nil {
+		data.Observed = make(map[string]int64)
+	}
+	if data.Statuses == nil {
+		data.Statuses = make(map[string]string)
+	}
+	if data.Transitions == nil {
+		data.Transitions = make(map[string][]model.StatusTransition)
+	}
+	go GlobalMinerEventHistory.flushLoop()
+}
+
+func (h *MinerEventHistory) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mutex.Lock()
+		if h.dirty {
+			if err := store.Save(minerEventStore, h.data); err != nil {
+				log.Logger.Errorf("Failed to save miner events to %s: %v", constant.DataPath, err)
+			}
+			h.dirty = false
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// record adds the new events of a miner and its current status, a status transition is recorded if the status changed
+func (h *MinerEventHistory) record(hostIP string, signatureAcc string, events []model.MinerEvent, status string, block uint32, now int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.data.Observed[signatureAcc]; !ok {
+		h.data.Observed[signatureAcc] = now
+		h.dirty = true
+	}
+	if prev, ok := h.data.Statuses[signatureAcc]; !ok || prev != status {
+		h.dirty = true
+		transitions := append(h.data.Transitions[signatureAcc], model.StatusTransition{
+			Host: hostIP, SignatureAcc: signatureAcc, From: prev, To: status, BlockId: block, Time: now,
+		})
+		// keep the last transition to know since when the current status lasts
+		drop := 0
+		for drop < len(transitions)-1 && now-transitions[drop].Time > constant.MinerEventRetention {
+			drop++
+		}
+		h.data.Transitions[signatureAcc] = append([]model.StatusTransition(nil), transitions[drop:]...)
+	}
+	h.data.Statuses[signatureAcc] = status
+
+	existing := h.data.Events[signatureAcc]
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[minerEventKey(e)] = true
+	}
+	appended := false
+	for _, e := range events {
+		if !seen[minerEventKey(e)] {
+			seen[minerEventKey(e)] = true
+			existing = append(existing, e)
+			appended = true
+		}
+	}
+	if !appended {
+		return
+	}
+	sort.SliceStable(existing, func(i, j int) bool { return existing[i].BlockId < existing[j].BlockId })
+	drop := 0
+	for drop < len(existing) && now-existing[drop].Time > constant.MinerEventRetention {
+		drop++
+	}
+	if over := len(existing) - drop - constant.MinerEventMaxPerMiner; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		existing = append([]model.MinerEvent(nil), existing[drop:]...)
+	}
+	h.data.Events[signatureAcc] = existing
+	h.dirty = true
+}
+
+func minerEventKey(e model.MinerEvent) string {
+	return fmt.Sprintf("%s/%d/%s", e.Kind, e.BlockId, e.ExtrinsicHash)
+}
+
+// Query returns the matched events as a timeline, the latest first
+func (h *MinerEventHistory) Query(filter model.MinerEventFilter) model.MinerEventList {
+	h.mutex.RLock()
+	matched := make([]model.MinerEvent, 0)
+	for acc, events := range h.data.Events {
+		if filter.Account != "" && acc != filter.Account {
+			continue
+		}
+		for _, e := range events {
+			if filter.Host != "" && e.Host != filter.Host ||
+				filter.Kind != "" && e.Kind != filter.Kind ||
+				filter.From > 0 && e.Time < filter.From ||
+				filter.To > 0 && e.Time > filter.To {
+				continue
+			}
+			matched = append(matched, e)
+		}
+	}
+	h.mutex.RUnlock()
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].BlockId != matched[j].BlockId {
+			return matched[i].BlockId > matched[j].BlockId
+		}
+		return matched[i].SignatureAcc < matched[j].SignatureAcc
+	})
+	res := model.MinerEventList{Count: len(matched), Content: []model.MinerEvent{}}
+	start := (filter.Page - 1) * filter.PageSize
+	if start < 0 || start >= len(matched) {
+		return res
+	}
+	end := start + filter.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	res.Content = matched[start:end]
+	return res
+}
+
+// lastSeen returns when the last event of the kind happened, or when watchdog started to observe the miner
+func (h *MinerEventHistory) lastSeen(signatureAcc string, kind string) int64 {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	last := h.data.Observed[signatureAcc]
+	for _, e := range h.data.Events[signatureAcc] {
+		if e.Kind == kind && e.Time > last {
+			last = e.Time
+		}
+	}
+	return last
+}
+
+// recordMinerEvents collects the events of a miner from the block queue and its status transitions,
+// then checks the status and the event rules
+func recordMinerEvents(hostIP string, signatureAcc string, status string, created int64, blockDataList []chain.BlockData, block uint32) {
+	if GlobalMinerEventHistory == nil {
+		return
+	}
+	var events []model.MinerEvent
+	newEvent := func(kind string, blockData chain.BlockData) model.MinerEvent {
+		return model.MinerEvent{Host: hostIP, SignatureAcc: signatureAcc, Kind: kind, BlockId: blockData.BlockId,
+			BlockHash: blockData.BlockHash, Success: true, Time: blockTime(blockData.Timestamp)}
+	}
+	for _, blockData := range blockDataList {
+		for _, ext := range blockData.Extrinsic {
+			if ext.Signer != signatureAcc {
+				continue
+			}
+			if kind := util.MinerEventKind(ext.Name); kind != "" {
+				e := newEvent(kind, blockData)
+				e.ExtrinsicHash, e.ExtrinsicName, e.Success = ext.Hash, ext.Name, ext.Result
+				e.Detail = strings.Join(ext.Events, ", ")
+				events = append(events, e)
+			}
+		}
+		for _, reg := range blockData.MinerReg {
+			if reg.Account == signatureAcc {
+				e := newEvent(constant.EventRegistration, blockData)
+				e.ExtrinsicHash, e.ExtrinsicName = reg.ExtrinsicHash, reg.ExtrinsicName
+				events = append(events, e)
+			}
+		}
+		for _, punish := range blockData.Punishment {
+			if punish.From == signatureAcc {
+				e := newEvent(constant.EventPunishment, blockData)
+				e.ExtrinsicHash, e.ExtrinsicName = punish.ExtrinsicHash, punish.ExtrinsicName
+				e.Detail = fmt.Sprintf("%s slashed to %s", punish.Amount, punish.To)
+				events = append(events, e)
+			}
+		}
+	}
+
+	now := time.Now().Unix()
+	prevStatus := GlobalMinerEventHistory.lastStatus(signatureAcc)
+	frozen := strings.EqualFold(status, constant.MinerFrozenStatus)
+	wasFrozen := strings.EqualFold(prevStatus, constant.MinerFrozenStatus)
+	if prevStatus != "" && frozen != wasFrozen {
+		kind := constant.EventFrozen
+		if wasFrozen {
+			kind = constant.EventUnfrozen
+		}
+		events = append(events, model.MinerEvent{Host: hostIP, SignatureAcc: signatureAcc, Kind: kind, BlockId: block, Success: true,
+			Detail: fmt.Sprintf("status changed from %s to %s", prevStatus, status), Time: now})
+	}
+	GlobalMinerEventHistory.record(hostIP, signatureAcc, events, status, block, now)
+	checkStatusTransition(hostIP, signatureAcc, created, block)
+	checkEventRules(hostIP, signatureAcc, status, block, now)
+}
+
+// checkStatusTransition alerts once for the latest transition of a miner to a non positive status,
+// and resolves the alert when the miner turns positive
+func checkStatusTransition(hostIP string, signatureAcc string, created int64, block uint32) {
+	h := GlobalMinerEventHistory
+	h.mutex.Lock()
+	transitions := h.data.Transitions[signatureAcc]
+	if len(transitions) == 0 {
+		h.mutex.Unlock()
+		return
+	}
+	last := &transitions[len(transitions)-1]
+	if last.To == constant.MinerPositiveStatus {
+		h.mutex.Unlock()
+		go resolveAlert(hostIP, constant.AlertKindMinerStatus, signatureAcc, "")
+		return
+	}
+	// do not alert if the miner is firstly created and not active yet
+	if last.Alerted || time.Now().Unix()-created <= 1800 {
+		h.mutex.Unlock()
+		return
+	}
+	last.Alerted = true
+	transition := *last
+	h.dirty = true
+	h.mutex.Unlock()
+
+	from := transition.From
+	if from == "" {
+		from = constant.Unknown
+	}
+	go doAlert(hostIP, constant.AlertKindMinerStatus, fmt.Sprintf("Host: %s, The Status of Storage Node %s on chain changed from %s to %s at block %d, which is not a positive status",
+		hostIP, signatureAcc, from, transition.To, transition.BlockId), signatureAcc, "", uint64(block))
+}
+
+// StatusTimeline returns the status transitions of a miner and the time spent in each status between from and to
+func (h *MinerEventHistory) StatusTimeline(signatureAcc string, from int64, to int64) (model.StatusTimeline, bool) {
+	h.mutex.RLock()
+	transitions := append([]model.StatusTransition(nil), h.data.Transitions[signatureAcc]...)
+	h.mutex.RUnlock()
+	if len(transitions) == 0 {
+		return model.StatusTimeline{}, false
+	}
+	last := transitions[len(transitions)-1]
+	timeline := model.StatusTimeline{
+		Host:         last.Host,
+		SignatureAcc: signatureAcc,
+		Name:         containerName(last.Host, "", signatureAcc),
+		Current:      last.To,
+		Since:        last.Time,
+		From:         from,
+		To:           to,
+		TimeInStatus: util.TimeInStatus(transitions, from, to),
+		Percent:      make(map[string]float64),
+	}
+	var observed int64
+	for _, seconds := range timeline.TimeInStatus {
+		observed += seconds
+	}
+	for status, seconds := range timeline.TimeInStatus {
+		timeline.Percent[status] = float64(seconds) / float64(observed) * 100
+	}
+	for _, t := range transitions {
+		if t.Time >= from && t.Time <= to {
+			timeline.Transitions = append(timeline.Transitions, t)
+		}
+	}
+	return timeline, true
+}
+
+// StatusTimelines returns the status timeline of each monitored miner without the transitions,
+// host is an optional filter
+func (h *MinerEventHistory) StatusTimelines(host string, from int64, to int64) []model.StatusTimeline {
+	var accounts []string
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		for acc := range cli.MinerInfoMap {
+			accounts = append(accounts, acc)
+		}
+		cli.mutex.Unlock()
+	}
+	res := make([]model.StatusTimeline, 0, len(accounts))
+	for _, acc := range accounts {
+		if timeline, ok := h.StatusTimeline(acc, from, to); ok {
+			timeline.Transitions = nil
+			res = append(res, timeline)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func (h *MinerEventHistory) lastStatus(signatureAcc string) string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.data.Statuses[signatureAcc]
+}
+
+// checkEventRules alerts if a positive miner has no event expected by the rules within their periods
+func checkEventRules(hostIP string, signatureAcc string, status string, block uint32, now int64) {
+	rules := CustomConfig.Alert.EventRules
+	if len(rules) == 0 {
+		return
+	}
+	if status != constant.MinerPositiveStatus {
+		go resolveAlert(hostIP, constant.AlertKindEventMissed, signatureAcc, "")
+		return
+	}
+	var missed []string
+	for _, rule := range rules {
+		if rule.Event == "" || rule.Within <= 0 {
+			continue
+		}
+		last := GlobalMinerEventHistory.lastSeen(signatureAcc, rule.Event)
+		if last > 0 && now-last > int64(rule.Within)*60 {
+			missed = append(missed, fmt.Sprintf("%s: no %s since %s", rule.Name, rule.Event, time.Unix(last, 0).Format(constant.TimeFormat)))
+		}
+	}
+	if len(missed) > 0 {
+		go doAlert(hostIP, constant.AlertKindEventMissed, fmt.Sprintf("Host: %s, Storage Node %s misses expected chain events, %s",
+			hostIP, signatureAcc, strings.Join(missed, "; ")), signatureAcc, "", uint64(block))
+	} else {
+		go resolveAlert(hostIP, constant.AlertKindEventMissed, signatureAcc, "")
+	}
+}
+
+// validateEventRules warns about the rules which can never fire
+func validateEventRules(rules []model.EventRule) {
+	for i, rule := range rules {
+		known := false
+		for _, kind := range minerEventKinds {
+			if rule.Event == kind {
+				known = true
+				break
+			}
+		}
+		if !known {
+			log.Logger.Warnf("Event rule %d %s: unknown event %q, should be one of %s", i, rule.Name, rule.Event, strings.Join(minerEventKinds, ", "))
+		}
+		if rule.Within <= 0 {
+			log.Logger.Warnf("Event rule %d %s: within must be greater than 0", i, rule.Name)
+		}
+	}
+}
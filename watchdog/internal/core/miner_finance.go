@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"github.com/CESSProject/cess-go-sdk/chain"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"math/big"
+	"sync"
+)
+
+const rewardProgressStore = "reward_progress"
+
+// rewardProgress remembers when the issued reward of a miner increased the last time
+type rewardProgress struct {
+	RewardIssued string `json:"reward_issued"` // unit: 10^-18 CESS
+	Block        uint32 `json:"block"`
+}
+
+var rewardTracker = struct {
+	sync.Mutex
+	loaded   bool
+	progress map[string]rewardProgress // key: signature account
+}{progress: make(map[string]rewardProgress)}
+
+// checkMinerFinance alerts when a miner has debt, not enough collaterals or its issued reward stops increasing
+func checkMinerFinance(hostIP string, signatureAcc string, status string, info chain.MinerInfo, reward chain.MinerReward, block uint32) {
+	thresholds := CustomConfig.Alert.Thresholds
+
+	debt := util.U128ToBigInt(types.U128(info.Debt))
+	if debt.Sign() > 0 {
+		doAlert(hostIP, constant.AlertKindDebt, fmt.Sprintf("Host: %s, Storage Node %s has a debt of %s CESS",
+			hostIP, signatureAcc, util.BigNumConversion(types.U128(info.Debt))), signatureAcc, "", uint64(block))
+	} else {
+		resolveAlert(hostIP, constant.AlertKindDebt, signatureAcc, "")
+	}
+
+	collaterals := util.U128ToBigInt(types.U128(info.Collaterals))
+	if msg := collateralShortage(collaterals, util.U128ToBigInt(types.U128(info.DeclarationSpace)), thresholds.MinCollateral, thresholds.CollateralPerTiB); msg != "" && status != constant.MinerExitStatus {
+		doAlert(hostIP, constant.AlertKindCollateral, fmt.Sprintf("Host: %s, The collaterals of Storage Node %s are %s CESS, %s",
+			hostIP, signatureAcc, util.BigNumConversion(types.U128(info.Collaterals)), msg), signatureAcc, "", uint64(block))
+	} else {
+		resolveAlert(hostIP, constant.AlertKindCollateral, signatureAcc, "")
+	}
+
+	if thresholds.RewardStallEras <= 0 {
+		return
+	}
+	stalled := rewardStalledBlocks(signatureAcc, util.U128ToBigInt(types.U128(reward.RewardIssued)), block)
+	if status == constant.MinerPositiveStatus && stalled >= uint32(thresholds.RewardStallEras)*constant.BlocksPerEra {
+		doAlert(hostIP, constant.AlertKindRewardStall, fmt.Sprintf("Host: %s, The issued reward of Storage Node %s has not increased for %d eras",
+			hostIP, signatureAcc, stalled/constant.BlocksPerEra), signatureAcc, "", uint64(block))
+	} else {
+		resolveAlert(hostIP, constant.AlertKindRewardStall, signatureAcc, "")
+	}
+}
+
+// collateralShortage returns why the collaterals are not enough, empty if they are enough
+func collateralShortage(collaterals *big.Int, declarationSpace *big.Int, minCollateral string, perTiB string) string {
+	if minCollateral != "" {
+		if min, err := util.ParseTokenAmount(minCollateral); err == nil && collaterals.Cmp(min) < 0 {
+			return fmt.Sprintf("below the configured minimum of %s CESS", minCollateral)
+		}
+	}
+	if perTiB == "" || declarationSpace.Sign() == 0 {
+		return ""
+	}
+	perTiBAmount, err := util.ParseTokenAmount(perTiB)
+	if err != nil {
+		return ""
+	}
+	// every started TiB of declared space needs perTiB collaterals
+	tib := big.NewInt(constant.Size1tib)
+	units := new(big.Int).Add(declarationSpace, new(big.Int).Sub(tib, big.NewInt(1)))
+	units.Quo(units, tib)
+	required := new(big.Int).Mul(units, perTiBAmount)
+	if collaterals.Cmp(required) < 0 {
+		return fmt.Sprintf("below %s CESS required by %s TiB of declared space", util.BigNumConversion(types.NewU128(*required)), units)
+	}
+	return ""
+}
+
+// rewardStalledBlocks returns the number of blocks since the issued reward of the miner increased the last time
+func rewardStalledBlocks(signatureAcc string, issued *big.Int, block uint32) uint32 {
+	rewardTracker.Lock()
+	defer rewardTracker.Unlock()
+	if !rewardTracker.loaded {
+		if err := store.Load(rewardProgressStore, &rewardTracker.progress); err != nil {
+			log.Logger.Warnf("Failed to load reward progress from %s: %v", constant.DataPath, err)
+		}
+		if rewardTracker.progress == nil {
+			rewardTracker.progress = make(map[string]rewardProgress)
+		}
+		rewardTracker.loaded = true
+	}
+	prev, ok := rewardTracker.progress[signatureAcc]
+	prevIssued, valid := new(big.Int).SetString(prev.RewardIssued, 10)
+	if !ok || !valid || issued.Cmp(prevIssued) > 0 || block < prev.Block {
+		rewardTracker.progress[signatureAcc] = rewardProgress{RewardIssued: issued.String(), Block: block}
+		if err := store.Save(rewardProgressStore, rewardTracker.progress); err != nil {
+			log.Logger.Errorf("Failed to save reward progress to %s: %v", constant.DataPath, err)
+		}
+		return 0
+	}
+	return block - prev.Block
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tcpProbeScript connects to $0:$1 with bash /dev/tcp and prints the latency in millisecond,
+// so the probe needs nothing but bash and coreutils in the container
+const tcpProbeScript = `start=$(date +%s%N); exec 3<>/dev/tcp/$0/$1 && echo $(( ($(date +%s%N) - start) / 1000000 ))`
+
+func probeTimeout() time.Duration {
+	if CustomConfig.Probe.Timeout <= 0 {
+		return constant.DefaultProbeTimeout * time.Second
+	}
+	return time.Duration(CustomConfig.Probe.Timeout) * time.Second
+}
+
+// probeInContainer reports whether the endpoints of a host should be probed inside the miner container
+func (cli *WatchdogClient) probeInContainer() bool {
+	switch strings.ToLower(CustomConfig.Probe.Mode) {
+	case constant.ProbeModeExec:
+		return true
+	case constant.ProbeModeDirect:
+		return false
+	default:
+		return cli.Host != "" && !util.IsLocalHost(cli.Host)
+	}
+}
+
+// probeEndpoint checks whether a tcp connection to the endpoint can be established, from the monitored
+// host inside the miner container or directly from watchdog
+func (cli *WatchdogClient) probeEndpoint(ctx context.Context, containerID string, endpoint string, inContainer bool) (time.Duration, error) {
+	host, port, err := util.ParseEndpoint(endpoint)
+	if err != nil {
+		return 0, err
+	}
+	timeout := probeTimeout()
+	if !inContainer {
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), timeout)
+		if err != nil {
+			return 0, err
+		}
+		conn.Close()
+		return time.Since(start), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout+10*time.Second)
+	defer cancel()
+	start := time.Now()
+	cmd := []string{"timeout", strconv.Itoa(int(timeout.Seconds())), "bash", "-c", tcpProbeScript, host, port}
+	exitCode, output, err := cli.Client.ExecProbe(ctx, containerID, cmd)
+	if err != nil {
+		return 0, err
+	}
+	switch exitCode {
+	case 0:
+	case 124:
+		return 0, fmt.Errorf("connect to %s:%s timed out", host, port)
+	default:
+		return 0, fmt.Errorf("connect to %s:%s failed with exit code %d: %s", host, port, exitCode, output)
+	}
+	if ms, err := strconv.ParseInt(output, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.Since(start), nil
+}
+
+// probeEndpoints probes the endpoints concurrently and updates the counters of the previous probes
+func (cli *WatchdogClient) probeEndpoints(ctx context.Context, containerID string, endpoints []string, prev []model.EndpointProbe, inContainer bool) []model.EndpointProbe {
+	probes := make([]model.EndpointProbe, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, probe model.EndpointProbe) {
+			defer wg.Done()
+			latency, err := cli.probeEndpoint(ctx, containerID, probe.Endpoint, inContainer)
+			probes[i] = updateProbe(probe, latency, err)
+		}(i, findProbe(prev, endpoint))
+	}
+	wg.Wait()
+	return probes
+}
+
+func findProbe(probes []model.EndpointProbe, endpoint string) model.EndpointProbe {
+	for _, p := range probes {
+		if p.Endpoint == endpoint {
+			return p
+		}
+	}
+	return model.EndpointProbe{Endpoint: endpoint}
+}
+
+// updateProbe applies the result of a check to the probe
+func updateProbe(probe model.EndpointProbe, latency time.Duration, err error) model.EndpointProbe {
+	probe.LastCheck = time.Now().Unix()
+	probe.TotalChecks++
+	if err != nil {
+		probe.Healthy = false
+		probe.LatencyMs = 0
+		probe.LastError = err.Error()
+		probe.Failures++
+		probe.TotalFailures++
+	} else {
+		probe.Healthy = true
+		probe.LatencyMs = latency.Milliseconds()
+		probe.LastError = ""
+		probe.LastSuccess = probe.LastCheck
+		probe.Failures = 0
+	}
+	return probe
+}
+
+// probeTees probes the tees in the config of a miner, and alerts if none of them is reachable
+func (cli *WatchdogClient) probeTees(ctx context.Context, miner *MinerInfo) {
+	cli.mutex.Lock()
+	endpoints := miner.Conf.Chain.TEEs
+	prev := miner.Tees
+	cli.mutex.Unlock()
+	if len(endpoints) == 0 {
+		return
+	}
+	probes := cli.probeEndpoints(ctx, miner.CInfo.ID, endpoints, prev, cli.probeInContainer())
+	cli.mutex.Lock()
+	miner.Tees = probes
+	cli.mutex.Unlock()
+
+	down := 0
+	var errs []string
+	for _, probe := range probes {
+		if probe.Failures >= constant.ProbeFailureThreshold {
+			down++
+			errs = append(errs, fmt.Sprintf("%s: %s", probe.Endpoint, probe.LastError))
+		}
+	}
+	if down == len(probes) {
+		go doAlert(cli.Host, constant.AlertKindTee, fmt.Sprintf("Host: %s, None of the %d TEEs of Storage Node %s is reachable: %s",
+			cli.Host, len(probes), miner.SignatureAcc, strings.Join(errs, "; ")), miner.SignatureAcc, miner.CInfo.ID, GlobalBlockDataManager.latestBlock)
+	} else {
+		for _, probe := range probes {
+			if probe.Healthy {
+				go resolveAlert(cli.Host, constant.AlertKindTee, miner.SignatureAcc, miner.CInfo.ID)
+				break
+			}
+		}
+	}
+}
+
+// probeMinerService probes the service port or api endpoint of a miner from watchdog
+func (cli *WatchdogClient) probeMinerService(ctx context.Context, miner *MinerInfo) {
+	cli.mutex.Lock()
+	endpoint := miner.Conf.App.APIEndpoint
+	if endpoint == "" && miner.Conf.App.Port > 0 {
+		endpoint = net.JoinHostPort(cli.Host, strconv.Itoa(miner.Conf.App.Port))
+	}
+	var prev model.EndpointProbe
+	if miner.ServicePort != nil {
+		prev = *miner.ServicePort
+	}
+	cli.mutex.Unlock()
+	if endpoint == "" {
+		return
+	}
+	endpoint = util.HostEndpoint(cli.Host, endpoint)
+	if prev.Endpoint != endpoint {
+		prev = model.EndpointProbe{Endpoint: endpoint}
+	}
+	latency, err := cli.probeEndpoint(ctx, miner.CInfo.ID, endpoint, false)
+	probe := updateProbe(prev, latency, err)
+	cli.mutex.Lock()
+	miner.ServicePort = &probe
+	cli.mutex.Unlock()
+
+	if probe.Failures >= constant.ProbeFailureThreshold {
+		go doAlert(cli.Host, constant.AlertKindMinerPort, fmt.Sprintf("Host: %s, The service endpoint %s of Storage Node %s is unreachable: %s",
+			cli.Host, endpoint, miner.SignatureAcc, probe.LastError), miner.SignatureAcc, miner.CInfo.ID, GlobalBlockDataManager.latestBlock)
+	} else if probe.Healthy {
+		go resolveAlert(cli.Host, constant.AlertKindMinerPort, miner.SignatureAcc, miner.CInfo.ID)
+	}
+}
+
+// referenceBlock returns the best block of the chain query rpc which the rpcs and chain nodes on the hosts are compared to,
+// or the latest block fetched if the rpc is unavailable
+func referenceBlock(ctx context.Context) uint64 {
+	if height, err := GlobalChainQuery.BlockNumber(ctx); err == nil {
+		return uint64(height)
+	}
+	return GlobalBlockDataManager.latestBlock
+}
+
+// probeMinerRpcs queries the block height of the rpcs of a miner from watchdog, and alerts if any of them
+// is unreachable or lags behind the reference chain client. A loopback rpc of a miner on a remote host is
+// relative to the miner container, which watchdog cannot reach, so it is skipped
+func (cli *WatchdogClient) probeMinerRpcs(miner *MinerInfo, reference uint64) {
+	cli.mutex.Lock()
+	var endpoints []string
+	for _, endpoint := range miner.Conf.Chain.RPCs {
+		if util.IsLocalHost(cli.Host) || !util.IsLocalEndpoint(endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	prev := miner.RPCs
+	cli.mutex.Unlock()
+	if len(endpoints) == 0 {
+		cli.mutex.Lock()
+		miner.RPCs = nil
+		cli.mutex.Unlock()
+		go resolveAlert(cli.Host, constant.AlertKindRpc, miner.SignatureAcc, miner.CInfo.ID)
+		return
+	}
+	probes := make([]model.RpcProbe, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		probe := model.RpcProbe{EndpointProbe: model.EndpointProbe{Endpoint: endpoint}}
+		for _, p := range prev {
+			if p.Endpoint == endpoint {
+				probe = p
+				break
+			}
+		}
+		wg.Add(1)
+		go func(i int, probe model.RpcProbe) {
+			defer wg.Done()
+			start := time.Now()
+			height, err := util.QueryRpcBlockHeight(probe.Endpoint, probeTimeout())
+			probe.EndpointProbe = updateProbe(probe.EndpointProbe, time.Since(start), err)
+			if err == nil {
+				probe.BlockHeight = height
+				probe.Lag = int64(reference) - int64(height)
+			}
+			probes[i] = probe
+		}(i, probe)
+	}
+	wg.Wait()
+	cli.mutex.Lock()
+	miner.RPCs = probes
+	cli.mutex.Unlock()
+
+	maxLag := int64(CustomConfig.Alert.Thresholds.RpcMaxLag)
+	var problems []string
+	for _, probe := range probes {
+		if probe.Failures >= constant.ProbeFailureThreshold {
+			problems = append(problems, fmt.Sprintf("%s is unreachable: %s", probe.Endpoint, probe.LastError))
+		} else if probe.Healthy && probe.Lag > maxLag {
+			problems = append(problems, fmt.Sprintf("%s lags %d blocks behind", probe.Endpoint, probe.Lag))
+		}
+	}
+	if len(problems) > 0 {
+		go doAlert(cli.Host, constant.AlertKindRpc, fmt.Sprintf("Host: %s, RPCs of Storage Node %s are unhealthy: %s",
+			cli.Host, miner.SignatureAcc, strings.Join(problems, "; ")), miner.SignatureAcc, miner.CInfo.ID, reference)
+	} else {
+		go resolveAlert(cli.Host, constant.AlertKindRpc, miner.SignatureAcc, miner.CInfo.ID)
+	}
+}
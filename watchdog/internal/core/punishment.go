@@ -0,0 +1,169 @@
+package core
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const punishmentStore = "punishments"
+
+// PunishmentHistory keeps the punishments of the monitored miners beyond the rolling block queue
+type PunishmentHistory struct {
+	mutex   sync.RWMutex
+	records []model.PunishmentRecord // ordered by block
+}
+
+var GlobalPunishmentHistory *PunishmentHistory
+
+func InitPunishmentHistory() {
+	if GlobalPunishmentHistory != nil {
+		return
+	}
+	GlobalPunishmentHistory = &PunishmentHistory{}
+	if err := store.Load(punishmentStore, &GlobalPunishmentHistory.records); err != nil {
+		log.Logger.Warnf("Failed to load punishments from %s: %v", constant.DataPath, err)
+	}
+}
+
+// Add records a punishment, and reports whether it is recorded the first time. A punishment is identified by its block
+// and extrinsic hash, and the account since the punishments when a challenge ends share an empty extrinsic hash
+func (h *PunishmentHistory) Add(record model.PunishmentRecord) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := len(h.records) - 1; i >= 0; i-- {
+		r := h.records[i]
+		if r.BlockId == record.BlockId && r.ExtrinsicHash == record.ExtrinsicHash && r.Account == record.Account {
+			return false
+		}
+	}
+	h.records = append(h.records, record)
+	sort.SliceStable(h.records, func(i, j int) bool { return h.records[i].BlockId < h.records[j].BlockId })
+	now := time.Now().Unix()
+	drop := 0
+	for drop < len(h.records) && now-h.records[drop].Time > constant.PunishmentRetention {
+		drop++
+	}
+	if over := len(h.records) - drop - constant.PunishmentMaxRecords; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		h.records = append([]model.PunishmentRecord(nil), h.records[drop:]...)
+	}
+	if err := store.Save(punishmentStore, h.records); err != nil {
+		log.Logger.Errorf("Failed to save punishments to %s: %v", constant.DataPath, err)
+	}
+	return true
+}
+
+// Query returns the matched punishments, the latest first
+func (h *PunishmentHistory) Query(filter model.PunishmentFilter) model.PunishmentRecordList {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	matched := make([]model.PunishmentRecord, 0)
+	for i := len(h.records) - 1; i >= 0; i-- {
+		record := h.records[i]
+		if filter.Host != "" && record.Host != filter.Host ||
+			filter.Account != "" && record.Account != filter.Account ||
+			filter.Reason != "" && record.Reason != filter.Reason ||
+			filter.From > 0 && record.Time < filter.From ||
+			filter.To > 0 && record.Time > filter.To {
+			continue
+		}
+		matched = append(matched, record)
+	}
+	res := model.PunishmentRecordList{Count: len(matched), Content: []model.PunishmentRecord{}}
+	start := (filter.Page - 1) * filter.PageSize
+	if start < 0 || start >= len(matched) {
+		return res
+	}
+	end := start + filter.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	res.Content = matched[start:end]
+	return res
+}
+
+// Stats counts the punishments and slashed amounts of each monitored miner in the last 24 hours, 7 and 30 days,
+// host and account are optional filters
+func (h *PunishmentHistory) Stats(host string, signatureAcc string) []model.PunishmentStats {
+	now := time.Now()
+	windows := []int64{now.Add(-24 * time.Hour).Unix(), now.AddDate(0, 0, -7).Unix(), now.AddDate(0, 0, -30).Unix()}
+	type counter struct {
+		stats   model.PunishmentStats
+		slashed [3]*big.Int
+	}
+	counters := make(map[string]*counter)
+	for hostIP, cli := range Clients {
+		if host != "" && hostIP != host || cli == nil {
+			continue
+		}
+		cli.mutex.Lock()
+		for acc, miner := range cli.MinerInfoMap {
+			if signatureAcc != "" && acc != signatureAcc {
+				continue
+			}
+			counters[hostIP+"/"+acc] = &counter{
+				stats:   model.PunishmentStats{Host: hostIP, SignatureAcc: acc, Name: miner.CInfo.Name},
+				slashed: [3]*big.Int{new(big.Int), new(big.Int), new(big.Int)},
+			}
+		}
+		cli.mutex.Unlock()
+	}
+
+	h.mutex.RLock()
+	for _, record := range h.records {
+		c, ok := counters[record.Host+"/"+record.Account]
+		if !ok {
+			continue
+		}
+		if record.Time > c.stats.LastPunishedAt {
+			c.stats.LastPunishedAt = record.Time
+		}
+		amount, ok := new(big.Int).SetString(record.Amount, 10)
+		if !ok {
+			amount = new(big.Int)
+		}
+		for i, window := range []*model.PunishmentWindow{&c.stats.Last24h, &c.stats.Last7d, &c.stats.Last30d} {
+			if record.Time < windows[i] {
+				continue
+			}
+			window.Count++
+			if util.IsIncorrectProof(record.Reason) {
+				window.IncorrectProof++
+			} else if util.IsMissingProof(record.Reason) {
+				window.NoProof++
+			}
+			if window.Reasons == nil {
+				window.Reasons = make(map[string]int)
+			}
+			window.Reasons[record.Reason]++
+			c.slashed[i].Add(c.slashed[i], amount)
+		}
+	}
+	h.mutex.RUnlock()
+
+	res := make([]model.PunishmentStats, 0, len(counters))
+	for _, c := range counters {
+		for i, window := range []*model.PunishmentWindow{&c.stats.Last24h, &c.stats.Last7d, &c.stats.Last30d} {
+			window.SlashedRaw = c.slashed[i].String()
+			window.Slashed = util.BigNumConversion(types.NewU128(*c.slashed[i]))
+		}
+		res = append(res, c.stats)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
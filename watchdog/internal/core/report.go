@@ -0,0 +1,275 @@
+package core
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const reportStore = "report_state"
+
+// minerSnapshot keeps the values of a miner at the last report to compute the changes in a period
+type minerSnapshot struct {
+	IdleSpace        string `json:"idle_space"`
+	ServiceSpace     string `json:"service_space"`
+	TotalReward      string `json:"total_reward"`
+	TotalRewardRaw   string `json:"total_reward_raw"`
+	ContainerID      string `json:"container_id"`
+	ContainerCreated int64  `json:"container_created"`
+	RestartCount     int    `json:"restart_count"`
+}
+
+type reportState struct {
+	LastReport int64                    `json:"last_report"` // unix timestamp
+	Miners     map[string]minerSnapshot `json:"miners"`      // key: signature account
+}
+
+var reporter = struct {
+	sync.Mutex
+	state   reportState
+	running bool
+}{}
+
+// InitReporter starts the scheduled report generator, the first report covers the period since watchdog started
+func InitReporter() {
+	reporter.Lock()
+	defer reporter.Unlock()
+	if reporter.running {
+		return
+	}
+	if err := store.Load(reportStore, &reporter.state); err != nil {
+		log.Logger.Warnf("Failed to load report state from %s: %v", constant.DataPath, err)
+	}
+	if reporter.state.LastReport == 0 {
+		reporter.state.LastReport = time.Now().Unix()
+	}
+	reporter.running = true
+	go runReporter()
+}
+
+func runReporter() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		conf := CustomConfig.Report
+		if !conf.Enable {
+			continue
+		}
+		now := time.Now()
+		scheduled, err := lastScheduledReportTime(conf, now)
+		if err != nil {
+			log.Logger.Warnf("Invalid report schedule: %v", err)
+			continue
+		}
+		reporter.Lock()
+		due := reporter.state.LastReport < scheduled.Unix()
+		reporter.Unlock()
+		if due {
+			sendReport(conf, now)
+		}
+	}
+}
+
+// lastScheduledReportTime returns the latest scheduled report time not after now
+func lastScheduledReportTime(conf model.ReportConfig, now time.Time) (time.Time, error) {
+	clock := conf.Time
+	if clock == "" {
+		clock = constant.ReportDefTime
+	}
+	hour, minute, err := util.ParseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	if conf.Schedule == constant.ReportWeekly {
+		day := conf.Weekday
+		if day == "" {
+			day = "mon"
+		}
+		weekday, err := util.ParseWeekday(day)
+		if err != nil {
+			return time.Time{}, err
+		}
+		for t.Weekday() != weekday {
+			t = t.AddDate(0, 0, -1)
+		}
+	}
+	return t, nil
+}
+
+// sendReport does not hold the reporter lock while sending, runReporter is the only sender
+func sendReport(conf model.ReportConfig, now time.Time) {
+	reporter.Lock()
+	report, snapshots := generateReport(reporter.state, now)
+	reporter.Unlock()
+
+	if SmtpConfig != nil && (len(conf.Receivers) == 0 || containsString(conf.Receivers, constant.ChannelEmail)) {
+		if err := SmtpConfig.SendReport(report); err != nil {
+			log.Logger.Errorf("Failed to send report email: %v", err)
+		}
+	}
+	message := util.BuildReportMessage(report)
+	for name, url := range reportWebhooks(conf.Receivers) {
+		hook, err := util.NewWebhookSender(url)
+		if err != nil {
+			continue
+		}
+		if _, err = hook.SendMessage(message); err != nil {
+			log.Logger.Errorf("Failed to send report to webhook %s: %v", name, err)
+		}
+	}
+
+	reporter.Lock()
+	reporter.state.LastReport = now.Unix()
+	reporter.state.Miners = snapshots
+	if err := store.Save(reportStore, reporter.state); err != nil {
+		log.Logger.Errorf("Failed to save report state to %s: %v", constant.DataPath, err)
+	}
+	reporter.Unlock()
+	log.Logger.Infof("%s from %s to %s sent", report.Title, report.PeriodStart, report.PeriodEnd)
+}
+
+// reportWebhooks returns the webhook urls by channel name which match the receivers
+func reportWebhooks(receivers []string) map[string]string {
+	res := make(map[string]string)
+	match := func(name string, url string) bool {
+		return len(receivers) == 0 || containsString(receivers, name) || containsString(receivers, util.GetWebhookType(url))
+	}
+	if WebhooksConfig != nil {
+		for _, url := range WebhooksConfig.Webhooks {
+			if name := util.WebhookChannelName(url); match(name, url) {
+				res[name] = url
+			}
+		}
+	}
+	for _, named := range CustomConfig.Alert.NamedWebhooks {
+		if match(named.Name, named.URL) {
+			res[named.Name] = named.URL
+		}
+	}
+	return res
+}
+
+// PreviewReport generates the report of the period since the last report without sending it
+func PreviewReport() model.Report {
+	reporter.Lock()
+	defer reporter.Unlock()
+	report, _ := generateReport(reporter.state, time.Now())
+	return report
+}
+
+func generateReport(state reportState, now time.Time) (model.Report, map[string]minerSnapshot) {
+	start := time.Unix(state.LastReport, 0)
+	report := model.Report{
+		Title:       constant.ReportTitle,
+		PeriodStart: start.Format(constant.TimeFormat),
+		PeriodEnd:   now.Format(constant.TimeFormat),
+		AlertCounts: make(map[string]int),
+		Hosts:       []model.HostReport{},
+	}
+	alertsByHost := make(map[string]int)
+	alertsByMiner := make(map[string]int)
+	if GlobalAlertHistory != nil {
+		records := GlobalAlertHistory.Query(model.AlertRecordFilter{From: start.Unix(), To: now.Unix(), Page: 1, PageSize: math.MaxInt32})
+		for _, record := range records.Content {
+			if record.Action != constant.AlertActionTrigger {
+				continue
+			}
+			report.TotalAlerts++
+			report.AlertCounts[record.Content.Severity]++
+			alertsByHost[record.Content.HostIp]++
+			if record.Content.SignatureAcc != "" {
+				alertsByMiner[record.Content.SignatureAcc]++
+			}
+		}
+	}
+
+	snapshots := make(map[string]minerSnapshot)
+	hosts := make([]string, 0, len(Clients))
+	for host := range Clients {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		cli := Clients[host]
+		if cli == nil {
+			continue
+		}
+		hostReport := model.HostReport{Host: host, Uptime: "-", Alerts: alertsByHost[host], Miners: []model.MinerReport{}}
+		if GlobalUptimeHistory != nil {
+			hostReport.Uptime = formatUptime(GlobalUptimeHistory.hostUptime(host, start.Unix(), now.Unix()))
+		}
+		cli.mutex.Lock()
+		for acc, miner := range cli.MinerInfoMap {
+			cur := minerSnapshot{
+				IdleSpace:        miner.MinerStat.IdleSpace,
+				ServiceSpace:     miner.MinerStat.ServiceSpace,
+				TotalReward:      miner.MinerStat.TotalReward,
+				TotalRewardRaw:   miner.MinerStat.TotalRewardRaw,
+				ContainerID:      miner.CInfo.ID,
+				ContainerCreated: miner.CInfo.Created,
+			}
+			if miner.CInfo.Metrics != nil {
+				cur.RestartCount = miner.CInfo.Metrics.RestartCount
+			}
+			prev, ok := state.Miners[acc]
+			if !ok {
+				prev = cur
+			}
+			restarts := cur.RestartCount - prev.RestartCount
+			if prev.ContainerID != cur.ContainerID || prev.ContainerCreated != cur.ContainerCreated {
+				// the restart count starts over in the recreated container
+				restarts = cur.RestartCount + 1
+			}
+			if restarts < 0 {
+				restarts = 0
+			}
+			uptime := "-"
+			if GlobalUptimeHistory != nil {
+				uptime = formatUptime(GlobalUptimeHistory.minerUptime(host, acc, start.Unix(), now.Unix()))
+			}
+			hostReport.Miners = append(hostReport.Miners, model.MinerReport{
+				Name:              miner.CInfo.Name,
+				SignatureAcc:      acc,
+				Status:            miner.MinerStat.Status,
+				ContainerState:    miner.CInfo.Status,
+				Uptime:            uptime,
+				DeclarationSpace:  miner.MinerStat.DeclarationSpace,
+				IdleSpace:         miner.MinerStat.IdleSpace,
+				PrevIdleSpace:     prev.IdleSpace,
+				ServiceSpace:      miner.MinerStat.ServiceSpace,
+				PrevServiceSpace:  prev.ServiceSpace,
+				TotalReward:       miner.MinerStat.TotalReward,
+				RewardAccrued:     util.RewardDiff(prev.TotalRewardRaw, cur.TotalRewardRaw),
+				Punishments:       punishmentCount(host, acc, start.Unix(), now.Unix()),
+				ContainerRestarts: restarts,
+				Alerts:            alertsByMiner[acc],
+			})
+			snapshots[acc] = cur
+		}
+		cli.mutex.Unlock()
+		sort.Slice(hostReport.Miners, func(i, j int) bool {
+			return hostReport.Miners[i].Name < hostReport.Miners[j].Name
+		})
+		report.Hosts = append(report.Hosts, hostReport)
+	}
+	return report, snapshots
+}
+
+// punishmentCount counts the punishments of a miner between from and to (unix timestamps of the blocks)
+func punishmentCount(host string, signatureAcc string, from int64, to int64) int {
+	if GlobalPunishmentHistory == nil {
+		return 0
+	}
+	filter := model.PunishmentFilter{Host: host, Account: signatureAcc, From: from, To: to, Page: 1, PageSize: 1}
+	return GlobalPunishmentHistory.Query(filter).Count
+}
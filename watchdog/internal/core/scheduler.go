@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scheduler runs the periodic tasks of the watchdog clients, each task on its own interval with jitter,
+// so a slow task, e.g. the chain queries, does not delay the others
+type Scheduler struct {
+	mutex sync.Mutex
+	tasks map[string]*model.ScheduledTask // key: host|task
+}
+
+var GlobalScheduler *Scheduler
+
+func InitScheduler() {
+	if GlobalScheduler != nil {
+		return
+	}
+	GlobalScheduler = &Scheduler{tasks: make(map[string]*model.ScheduledTask)}
+}
+
+// Schedule runs a task of a host every interval with jitter until ctx is done, the first run is after delay
+func (s *Scheduler) Schedule(ctx context.Context, host string, name string, interval time.Duration, delay time.Duration, run func(ctx context.Context)) {
+	key := host + "|" + name
+	task := &model.ScheduledTask{Host: host, Task: name, Interval: int(interval / time.Second), NextRun: time.Now().Add(delay).Unix()}
+	s.mutex.Lock()
+	s.tasks[key] = task
+	s.mutex.Unlock()
+
+	go func() {
+		defer func() {
+			s.mutex.Lock()
+			if s.tasks[key] == task {
+				delete(s.tasks, key)
+			}
+			s.mutex.Unlock()
+		}()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			start := time.Now()
+			s.mutex.Lock()
+			task.Running = true
+			task.LastRun = start.Unix()
+			s.mutex.Unlock()
+
+			run(ctx)
+
+			next := util.Jitter(interval, scheduleJitter(CustomConfig.Schedules), rand.Float64())
+			elapsed := time.Since(start)
+			s.mutex.Lock()
+			task.Running = false
+			task.Runs++
+			task.LastDuration = elapsed.Milliseconds()
+			task.NextRun = time.Now().Add(next).Unix()
+			s.mutex.Unlock()
+			log.Logger.Debugf("Task %s of host %s finished in %v, next run in %v", name, host, elapsed, next)
+			timer.Reset(next)
+		}
+	}()
+}
+
+// Running reports whether any task of a host is running
+func (s *Scheduler) Running(host string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, task := range s.tasks {
+		if task.Host == host && task.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// Tasks returns the state of the scheduled tasks, host is an optional filter
+func (s *Scheduler) Tasks(host string) []model.ScheduledTask {
+	s.mutex.Lock()
+	res := make([]model.ScheduledTask, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		if host == "" || task.Host == host {
+			res = append(res, *task)
+		}
+	}
+	s.mutex.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Task < res[j].Task
+	})
+	return res
+}
+
+// scheduleJitter returns the jitter percent of the schedules, 0 if it is not set
+func scheduleJitter(schedules model.ScheduleConfig) int {
+	if schedules.Jitter == nil {
+		return 0
+	}
+	return *schedules.Jitter
+}
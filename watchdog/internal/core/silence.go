@@ -0,0 +1,193 @@
+package core
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	silenceStore = "silences"
+	// expired silences are kept for a while so operators can look back at them
+	silenceRetention = 7 * 24 * 3600 // unit: second
+)
+
+// SilenceManager keeps the silences created via API, silenced alerts are recorded but not delivered
+type SilenceManager struct {
+	mutex    sync.RWMutex
+	silences []model.Silence
+	matchers map[string][]util.Matcher // compiled matchers, key: silence id
+}
+
+var GlobalSilenceManager *SilenceManager
+
+func InitSilenceManager() {
+	if GlobalSilenceManager != nil {
+		return
+	}
+	GlobalSilenceManager = &SilenceManager{matchers: make(map[string][]util.Matcher)}
+	if err := store.Load(silenceStore, &GlobalSilenceManager.silences); err != nil {
+		log.Logger.Warnf("Failed to load silences from %s: %v", constant.DataPath, err)
+	}
+	for _, s := range GlobalSilenceManager.silences {
+		matchers, err := util.CompileMatchers(s.Matchers, alertLabelNames)
+		if err != nil {
+			log.Logger.Warnf("Silence %s will be ignored: %v", s.ID, err)
+			continue
+		}
+		GlobalSilenceManager.matchers[s.ID] = matchers
+	}
+}
+
+// maintenanceWindows are the valid maintenance windows of the current config with the matchers compiled
+var maintenanceWindows []compiledWindow
+
+type compiledWindow struct {
+	model.MaintenanceWindow
+	matchers []util.Matcher
+}
+
+// compileMaintenanceWindows is called when the config is loaded, the invalid windows are ignored
+func compileMaintenanceWindows(windows []model.MaintenanceWindow) []compiledWindow {
+	res := make([]compiledWindow, 0, len(windows))
+	for _, window := range windows {
+		if err := util.ValidateMaintenanceWindow(window); err != nil {
+			log.Logger.Warnf("Maintenance window %s will be ignored: %v", window.Name, err)
+			continue
+		}
+		matchers, err := util.CompileMatchers(window.Matchers, alertLabelNames)
+		if err != nil {
+			log.Logger.Warnf("Maintenance window %s will be ignored: %v", window.Name, err)
+			continue
+		}
+		res = append(res, compiledWindow{MaintenanceWindow: window, matchers: matchers})
+	}
+	return res
+}
+
+// alertLabelNames are the labels which matchers of routes, silences and maintenance windows may use
+var alertLabelNames = []string{
+	constant.MatcherHost,
+	constant.MatcherGroup,
+	constant.MatcherAccount,
+	constant.MatcherContainer,
+	constant.MatcherKind,
+	constant.MatcherSeverity,
+}
+
+func alertLabels(content model.AlertContent) map[string][]string {
+	return map[string][]string{
+		constant.MatcherHost:      {content.HostIp},
+		constant.MatcherGroup:     {hostGroup(content.HostIp)},
+		constant.MatcherAccount:   {content.SignatureAcc},
+		constant.MatcherContainer: {content.ContainerID, content.ContainerName},
+		constant.MatcherKind:      {content.Kind},
+		constant.MatcherSeverity:  {content.Severity},
+	}
+}
+
+func (sm *SilenceManager) Create(silence model.Silence) (model.Silence, error) {
+	now := time.Now().Unix()
+	if len(silence.Matchers) == 0 {
+		return silence, fmt.Errorf("a silence needs at least one matcher")
+	}
+	matchers, err := util.CompileMatchers(silence.Matchers, alertLabelNames)
+	if err != nil {
+		return silence, err
+	}
+	if silence.StartsAt == 0 {
+		silence.StartsAt = now
+	}
+	if silence.EndsAt <= silence.StartsAt || silence.EndsAt <= now {
+		return silence, fmt.Errorf("ends_at must be later than starts_at and now")
+	}
+	silence.ID = util.NewID()
+	silence.CreatedAt = now
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	kept := sm.silences[:0]
+	for _, s := range sm.silences {
+		if now-s.EndsAt < silenceRetention {
+			kept = append(kept, s)
+		} else {
+			delete(sm.matchers, s.ID)
+		}
+	}
+	sm.silences = append(kept, silence)
+	sm.matchers[silence.ID] = matchers
+	sm.save()
+	log.Logger.Infof("Silence %s created by %s until %s: %s", silence.ID, silence.CreatedBy,
+		time.Unix(silence.EndsAt, 0).Format(constant.TimeFormat), silence.Comment)
+	return silence, nil
+}
+
+// Expire ends a silence immediately
+func (sm *SilenceManager) Expire(id string) error {
+	now := time.Now().Unix()
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	for i := range sm.silences {
+		if sm.silences[i].ID != id {
+			continue
+		}
+		if sm.silences[i].EndsAt > now {
+			sm.silences[i].EndsAt = now
+			sm.save()
+		}
+		return nil
+	}
+	return fmt.Errorf("silence %s not found", id)
+}
+
+// List returns the silences ordered by end time, expired ones only if requested
+func (sm *SilenceManager) List(includeExpired bool) []model.Silence {
+	now := time.Now().Unix()
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	res := make([]model.Silence, 0, len(sm.silences))
+	for _, s := range sm.silences {
+		if includeExpired || s.EndsAt > now {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].EndsAt > res[j].EndsAt
+	})
+	return res
+}
+
+// silencedBy returns the id of the active silence or the name of the maintenance window matching the alert
+func silencedBy(content model.AlertContent) (string, bool) {
+	now := time.Now()
+	labels := alertLabels(content)
+	if GlobalSilenceManager != nil {
+		GlobalSilenceManager.mutex.RLock()
+		defer GlobalSilenceManager.mutex.RUnlock()
+		for _, s := range GlobalSilenceManager.silences {
+			matchers, ok := GlobalSilenceManager.matchers[s.ID]
+			if ok && s.StartsAt <= now.Unix() && now.Unix() < s.EndsAt && util.MatchLabels(labels, matchers) {
+				return s.ID, true
+			}
+		}
+	}
+	for _, window := range maintenanceWindows {
+		if active, _ := util.InMaintenanceWindow(window.MaintenanceWindow, now); active && util.MatchLabels(labels, window.matchers) {
+			return window.Name, true
+		}
+	}
+	return "", false
+}
+
+// save must be called with sm.mutex held
+func (sm *SilenceManager) save() {
+	if err := store.Save(silenceStore, sm.silences); err != nil {
+		log.Logger.Errorf("Failed to save silences to %s: %v", constant.DataPath, err)
+	}
+}
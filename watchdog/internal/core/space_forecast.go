@@ -0,0 +1,197 @@
+package core
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const spaceHistoryStore = "space_history"
+
+// SpaceHistory keeps the space samples of each miner collected by every chain scrape,
+// it is flushed to the data directory periodically
+type SpaceHistory struct {
+	mutex   sync.RWMutex
+	samples map[string]map[string][]model.SpaceSample // host -> signature account -> samples ordered by time
+	dirty   bool
+}
+
+var GlobalSpaceHistory *SpaceHistory
+
+func InitSpaceHistory() {
+	if GlobalSpaceHistory != nil {
+		return
+	}
+	GlobalSpaceHistory = &SpaceHistory{}
+	if err := store.Load(spaceHistoryStore, &GlobalSpaceHistory.samples); err != nil {
+		log.Logger.Warnf("Failed to load space history from %s: %v", constant.DataPath, err)
+	}
+	if GlobalSpaceHistory.samples == nil {
+		GlobalSpaceHistory.samples = make(map[string]map[string][]model.SpaceSample)
+	}
+	go GlobalSpaceHistory.flushLoop()
+}
+
+// Add records a sample and returns the forecast of the miner
+func (h *SpaceHistory) Add(hostIP string, signatureAcc string, stat model.MinerStat, now time.Time) model.SpaceForecast {
+	sample := model.SpaceSample{
+		Time:        now.Unix(),
+		Declaration: stat.DeclarationSpaceBytes,
+		Idle:        stat.IdleSpaceBytes,
+		Service:     stat.ServiceSpaceBytes,
+		Lock:        stat.LockSpaceBytes,
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.samples[hostIP] == nil {
+		h.samples[hostIP] = make(map[string][]model.SpaceSample)
+	}
+	samples := append(h.samples[hostIP][signatureAcc], sample)
+	drop := 0
+	for drop < len(samples) && sample.Time-samples[drop].Time > constant.SpaceHistoryRetention {
+		drop++
+	}
+	if over := len(samples) - drop - constant.SpaceHistoryMaxSamples; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		samples = append([]model.SpaceSample(nil), samples[drop:]...)
+	}
+	h.samples[hostIP][signatureAcc] = samples
+	h.dirty = true
+	return forecastSpace(samples, now)
+}
+
+func (h *SpaceHistory) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mutex.Lock()
+		if h.dirty {
+			if err := store.Save(spaceHistoryStore, h.samples); err != nil {
+				log.Logger.Errorf("Failed to save space history to %s: %v", constant.DataPath, err)
+			}
+			h.dirty = false
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// Samples returns a copy of the samples of a miner
+func (h *SpaceHistory) Samples(hostIP string, signatureAcc string) []model.SpaceSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return append([]model.SpaceSample{}, h.samples[hostIP][signatureAcc]...)
+}
+
+// Forecasts returns the forecast of every miner of the monitored hosts, host and account are optional filters
+func (h *SpaceHistory) Forecasts(host string, signatureAcc string) []model.SpaceForecast {
+	now := time.Now()
+	h.mutex.RLock()
+	res := make([]model.SpaceForecast, 0)
+	for hostIP, miners := range h.samples {
+		if host != "" && hostIP != host || Clients[hostIP] == nil {
+			continue
+		}
+		for acc, samples := range miners {
+			if signatureAcc != "" && acc != signatureAcc {
+				continue
+			}
+			forecast := forecastSpace(samples, now)
+			forecast.Host = hostIP
+			forecast.SignatureAcc = acc
+			res = append(res, forecast)
+		}
+	}
+	h.mutex.RUnlock()
+	for i := range res {
+		res[i].Name = containerName(res[i].Host, "", res[i].SignatureAcc)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Host != res[j].Host {
+			return res[i].Host < res[j].Host
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// forecastSpace fits the samples in the forecast window linearly and projects when the idle space runs out
+// and when the declared space is fully certified
+func forecastSpace(samples []model.SpaceSample, now time.Time) model.SpaceForecast {
+	var forecast model.SpaceForecast
+	if len(samples) == 0 {
+		return forecast
+	}
+	last := samples[len(samples)-1]
+	forecast.DeclarationSpace = last.Declaration
+	forecast.IdleSpace = last.Idle
+	forecast.ServiceSpace = last.Service
+	forecast.LockSpace = last.Lock
+	forecast.UpdatedAt = last.Time
+
+	var xs, idle, service, certified []float64
+	for _, s := range samples {
+		if now.Unix()-s.Time > constant.SpaceForecastWindow {
+			continue
+		}
+		day := float64(s.Time-last.Time) / (24 * 3600)
+		xs = append(xs, day)
+		idle = append(idle, float64(s.Idle))
+		service = append(service, float64(s.Service))
+		certified = append(certified, float64(s.Idle)+float64(s.Service))
+	}
+	forecast.Samples = len(xs)
+	if len(xs) < 2 || (xs[len(xs)-1]-xs[0])*24*3600 < constant.SpaceForecastMinSpan {
+		return forecast
+	}
+	forecast.FillRate, _, _ = util.LinearRegression(xs, service)
+	forecast.IdleRate, _, _ = util.LinearRegression(xs, idle)
+	forecast.CertifyRate, _, _ = util.LinearRegression(xs, certified)
+
+	if forecast.IdleRate < 0 {
+		forecast.IdleExhaustedAt = projectTime(last.Time, float64(last.Idle)/-forecast.IdleRate)
+	}
+	certifiedNow := float64(last.Idle) + float64(last.Service)
+	switch {
+	case certifiedNow >= float64(last.Declaration):
+		forecast.FullyCertifiedAt = last.Time
+	case forecast.CertifyRate > 0:
+		forecast.FullyCertifiedAt = projectTime(last.Time, (float64(last.Declaration)-certifiedNow)/forecast.CertifyRate)
+	}
+	return forecast
+}
+
+// projectTime adds days to the unix timestamp, 0 if the result is too far away to be meaningful
+func projectTime(from int64, days float64) int64 {
+	if math.IsInf(days, 0) || math.IsNaN(days) || days > 100*365 {
+		return 0
+	}
+	return from + int64(days*24*3600)
+}
+
+// checkSpaceForecast records the space of a miner and alerts if the idle space is projected to run out soon
+func checkSpaceForecast(hostIP string, signatureAcc string, stat model.MinerStat, block uint32) {
+	if GlobalSpaceHistory == nil {
+		return
+	}
+	now := time.Now()
+	forecast := GlobalSpaceHistory.Add(hostIP, signatureAcc, stat, now)
+	days := CustomConfig.Alert.Thresholds.SpaceFullDays
+	if days <= 0 {
+		return
+	}
+	if forecast.IdleExhaustedAt > 0 && forecast.IdleExhaustedAt-now.Unix() < int64(days)*24*3600 {
+		doAlert(hostIP, constant.AlertKindSpaceFull, fmt.Sprintf("Host: %s, The idle space of Storage Node %s (%s left) is projected to run out at %s",
+			hostIP, signatureAcc, stat.IdleSpace, time.Unix(forecast.IdleExhaustedAt, 0).Format(constant.TimeFormat)), signatureAcc, "", uint64(block))
+	} else {
+		resolveAlert(hostIP, constant.AlertKindSpaceFull, signatureAcc, "")
+	}
+}
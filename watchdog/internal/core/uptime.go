@@ -0,0 +1,246 @@
+package core
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/store"
+	"github.com/CESSProject/watchdog/internal/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+const uptimeHistoryStore = "uptime_history"
+
+type uptimeData struct {
+	Hosts  map[string][]model.UptimeSample            `json:"hosts"`  // key: host
+	Miners map[string]map[string][]model.UptimeSample `json:"miners"` // host -> signature account -> samples
+}
+
+// UptimeHistory keeps the availability of the hosts and miners observed by every scrape,
+// it is flushed to the data directory periodically
+type UptimeHistory struct {
+	mutex sync.RWMutex
+	data  uptimeData
+	dirty bool
+}
+
+var GlobalUptimeHistory *UptimeHistory
+
+func InitUptimeHistory() {
+	if GlobalUptimeHistory != nil {
+		return
+	}
+	GlobalUptimeHistory = &UptimeHistory{}
+	if err := store.Load(uptimeHistoryStore, &GlobalUptimeHistory.data); err != nil {
+		log.Logger.Warnf("Failed to load uptime history from %s: %v", constant.DataPath, err)
+	}
+	if GlobalUptimeHistory.data.Hosts == nil {
+		GlobalUptimeHistory.data.Hosts = make(map[string][]model.UptimeSample)
+	}
+	if GlobalUptimeHistory.data.Miners == nil {
+		GlobalUptimeHistory.data.Miners = make(map[string]map[string][]model.UptimeSample)
+	}
+	go GlobalUptimeHistory.flushLoop()
+}
+
+func appendUptimeSample(samples []model.UptimeSample, sample model.UptimeSample) []model.UptimeSample {
+	samples = append(samples, sample)
+	drop := 0
+	for drop < len(samples) && sample.Time-samples[drop].Time > constant.UptimeHistoryRetention {
+		drop++
+	}
+	if over := len(samples) - drop - constant.UptimeHistoryMaxSamples; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		samples = append([]model.UptimeSample(nil), samples[drop:]...)
+	}
+	return samples
+}
+
+// AddHost records whether the docker daemon of a host is reachable
+func (h *UptimeHistory) AddHost(hostIP string, up bool, now int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.data.Hosts[hostIP] = appendUptimeSample(h.data.Hosts[hostIP], model.UptimeSample{Time: now, Up: up})
+	h.dirty = true
+}
+
+// AddMiners records the availability of the miners of a host, a miner which has been seen recently
+// but is missing now is recorded as down
+func (h *UptimeHistory) AddMiners(hostIP string, samples map[string]model.UptimeSample, now int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	miners := h.data.Miners[hostIP]
+	if miners == nil {
+		miners = make(map[string][]model.UptimeSample)
+		h.data.Miners[hostIP] = miners
+	}
+	for acc, history := range miners {
+		if _, ok := samples[acc]; ok {
+			continue
+		}
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Up {
+				if now-history[i].Time < constant.UptimeMissingGrace {
+					miners[acc] = appendUptimeSample(history, model.UptimeSample{Time: now})
+				}
+				break
+			}
+		}
+	}
+	for acc, sample := range samples {
+		miners[acc] = appendUptimeSample(miners[acc], sample)
+	}
+	h.dirty = true
+}
+
+func (h *UptimeHistory) flushLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mutex.Lock()
+		if h.dirty {
+			if err := store.Save(uptimeHistoryStore, h.data); err != nil {
+				log.Logger.Errorf("Failed to save uptime history to %s: %v", constant.DataPath, err)
+			}
+			h.dirty = false
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// uptimeMaxGap is how long a sample of a task with the interval holds, a longer gap means watchdog was not running
+func uptimeMaxGap(interval int) int64 {
+	return int64(2 * interval * (100 + scheduleJitter(CustomConfig.Schedules)) / 100)
+}
+
+func (h *UptimeHistory) hostUptime(hostIP string, from int64, to int64) model.UptimeWindow {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return util.Uptime(h.data.Hosts[hostIP], from, to, uptimeMaxGap(CustomConfig.Schedules.Discovery), false)
+}
+
+func (h *UptimeHistory) minerUptime(hostIP string, signatureAcc string, from int64, to int64) model.UptimeWindow {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return util.Uptime(h.data.Miners[hostIP][signatureAcc], from, to, uptimeMaxGap(CustomConfig.Schedules.Chain), true)
+}
+
+// Report computes the uptime of the hosts and miners in each configured window, host is an optional filter
+func (h *UptimeHistory) Report(host string) model.UptimeReport {
+	conf := CustomConfig.Uptime
+	now := time.Now()
+	res := model.UptimeReport{Target: conf.Target, Hosts: []model.HostUptime{}, Miners: []model.MinerUptime{}}
+	windows := func(uptime func(from int64) model.UptimeWindow) []model.UptimeWindow {
+		list := make([]model.UptimeWindow, 0, len(conf.Windows))
+		for _, days := range conf.Windows {
+			window := uptime(now.AddDate(0, 0, -days).Unix())
+			window.Days = days
+			window.MeetsTarget = window.Observed > 0 && window.Uptime >= conf.Target
+			list = append(list, window)
+		}
+		return list
+	}
+
+	h.mutex.RLock()
+	type minerKey struct{ host, acc string }
+	var hosts []string
+	var miners []minerKey
+	for hostIP := range h.data.Hosts {
+		if host == "" || hostIP == host {
+			hosts = append(hosts, hostIP)
+		}
+	}
+	for hostIP, accounts := range h.data.Miners {
+		if host != "" && hostIP != host {
+			continue
+		}
+		for acc := range accounts {
+			miners = append(miners, minerKey{hostIP, acc})
+		}
+	}
+	h.mutex.RUnlock()
+
+	for _, hostIP := range hosts {
+		if Clients[hostIP] == nil {
+			continue
+		}
+		res.Hosts = append(res.Hosts, model.HostUptime{Host: hostIP, Windows: windows(func(from int64) model.UptimeWindow {
+			return h.hostUptime(hostIP, from, now.Unix())
+		})})
+	}
+	for _, m := range miners {
+		if Clients[m.host] == nil {
+			continue
+		}
+		res.Miners = append(res.Miners, model.MinerUptime{
+			Host:         m.host,
+			SignatureAcc: m.acc,
+			Name:         containerName(m.host, "", m.acc),
+			Windows: windows(func(from int64) model.UptimeWindow {
+				return h.minerUptime(m.host, m.acc, from, now.Unix())
+			}),
+		})
+	}
+	sort.Slice(res.Hosts, func(i, j int) bool { return res.Hosts[i].Host < res.Hosts[j].Host })
+	sort.Slice(res.Miners, func(i, j int) bool {
+		if res.Miners[i].Host != res.Miners[j].Host {
+			return res.Miners[i].Host < res.Miners[j].Host
+		}
+		if res.Miners[i].Name != res.Miners[j].Name {
+			return res.Miners[i].Name < res.Miners[j].Name
+		}
+		return res.Miners[i].SignatureAcc < res.Miners[j].SignatureAcc
+	})
+	return res
+}
+
+// formatUptime formats the uptime of a window for reports, - if nothing was observed
+func formatUptime(window model.UptimeWindow) string {
+	if window.Observed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", window.Uptime)
+}
+
+// recordHostUptime records whether docker of the host is reachable after a discovery
+func (cli *WatchdogClient) recordHostUptime(up bool) {
+	cli.mutex.Lock()
+	cli.dockerDown = !up
+	cli.mutex.Unlock()
+	if GlobalUptimeHistory == nil {
+		return
+	}
+	GlobalUptimeHistory.AddHost(cli.Host, up, time.Now().Unix())
+}
+
+// recordMinerUptime records the availability of the miners of a host after a chain query
+func (cli *WatchdogClient) recordMinerUptime() {
+	if GlobalUptimeHistory == nil {
+		return
+	}
+	now := time.Now().Unix()
+	samples := make(map[string]model.UptimeSample)
+	cli.mutex.Lock()
+	if cli.dockerDown {
+		// the miners are unknown if docker is unreachable
+		cli.mutex.Unlock()
+		return
+	}
+	for acc, miner := range cli.MinerInfoMap {
+		sample := model.UptimeSample{
+			Time:     now,
+			Running:  miner.CInfo.State == "running",
+			Positive: miner.MinerStat.Status == constant.MinerPositiveStatus,
+			Punished: len(miner.MinerStat.LatestPunishInfo) > 0,
+		}
+		sample.Up = sample.Running && sample.Positive && !sample.Punished
+		samples[acc] = sample
+	}
+	cli.mutex.Unlock()
+	GlobalUptimeHistory.AddMiners(cli.Host, samples, now)
+}
@@ -6,37 +6,83 @@ type HostItem struct {
 	CAPath   string `yaml:"ca_path,omitempty"`   // /etc/docker/127.0.0.1/ca.pem
 	CertPath string `yaml:"cert_path,omitempty"` // /etc/docker/127.0.0.1/cert.pem
 	KeyPath  string `yaml:"key_path,omitempty"`  // /etc/docker/127.0.0.1/key.pem
+	Group    string `yaml:"group,omitempty"`     // used by alert routes and silences, e.g. dc-eu
+	// Selectors select the monitored containers on the host, defaults to the global selectors
+	Selectors []ContainerSelector `yaml:"selectors,omitempty"`
+	// ChainRpcs are the rpc endpoints of the chain nodes on the host, defaults to port 9944 of the host if a chain container is selected
+	ChainRpcs []string `yaml:"chain_rpcs,omitempty"`
+}
+
+// ContainerSelector selects the containers of a component, a container is selected if all the given conditions match,
+// image, name and exclude are globs (* and ?) or anchored regexes if is_regex
+type ContainerSelector struct {
+	Component string            `yaml:"component,omitempty" json:"component,omitempty"` // miner (default), chain or tee
+	Image     string            `yaml:"image,omitempty" json:"image,omitempty"`
+	Name      string            `yaml:"name,omitempty" json:"name,omitempty"`
+	IsRegex   bool              `yaml:"is_regex,omitempty" json:"is_regex,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`   // required labels, an empty value only requires the label to exist
+	Exclude   []string          `yaml:"exclude,omitempty" json:"exclude,omitempty"` // names of the containers to skip
 }
 
 type AlertContent struct {
-	AlertTime    string
-	HostIp       string
-	Description  string
-	DetailUrl    string
-	SignatureAcc string
-	ContainerID  string
-	BlockNumber  uint64
+	AlertTime     string
+	HostIp        string
+	Description   string
+	DetailUrl     string
+	SignatureAcc  string
+	ContainerID   string
+	ContainerName string
+	BlockNumber   uint64
+	Kind          string // alert kind, e.g. miner_status, punishment
+	Severity      string // critical, error, warning, info
 }
 
 type Container struct {
-	ID            string   `json:"id"`
-	Names         []string `json:"names"`
-	Name          string   `json:"name"`
-	Image         string   `json:"image"`
-	ImageID       string   `json:"image_id"`
-	Command       string   `json:"command"`
-	Created       int64    `json:"created"`
-	State         string   `json:"state"`
-	Status        string   `json:"status"`
-	CPUPercent    string   `json:"cpu_percent"`
-	MemoryPercent string   `json:"memory_percent"`
-	MemoryUsage   string   `json:"mem_usage"`
+	ID            string            `json:"id"`
+	Names         []string          `json:"names"`
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	ImageID       string            `json:"image_id"`
+	Command       string            `json:"command"`
+	Created       int64             `json:"created"`
+	State         string            `json:"state"`
+	Status        string            `json:"status"`
+	CPUPercent    string            `json:"cpu_percent"`
+	MemoryPercent string            `json:"memory_percent"`
+	MemoryUsage   string            `json:"mem_usage"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Ports         []int             `json:"ports,omitempty"` // published tcp ports on the host
+	// Metrics are the numeric resource metrics of the last scrape
+	Metrics *ContainerMetrics `json:"metrics,omitempty"`
 }
 
 type ContainerStat struct {
 	CPUPercent    string
 	MemoryPercent string
 	MemoryUsage   string
+	Metrics       ContainerMetrics
+}
+
+// ContainerMetrics are collected from the container stats and inspect api, sizes are in bytes
+// and rates are in bytes per second since the previous scrape
+type ContainerMetrics struct {
+	CPUPercent     float64 `json:"cpu_percent"`
+	MemoryPercent  float64 `json:"memory_percent"`
+	MemoryUsage    uint64  `json:"memory_usage"`
+	MemoryLimit    uint64  `json:"memory_limit"`
+	NetworkRx      uint64  `json:"network_rx"`
+	NetworkTx      uint64  `json:"network_tx"`
+	NetworkRxRate  float64 `json:"network_rx_rate"`
+	NetworkTxRate  float64 `json:"network_tx_rate"`
+	BlockRead      uint64  `json:"block_read"`
+	BlockWrite     uint64  `json:"block_write"`
+	BlockReadRate  float64 `json:"block_read_rate"`
+	BlockWriteRate float64 `json:"block_write_rate"`
+	PIDs           uint64  `json:"pids"`
+	RestartCount   int     `json:"restart_count"`
+	OOMKilled      bool    `json:"oom_killed"`
+	StartedAt      string  `json:"started_at"`
+	UpdatedAt      int64   `json:"updated_at"` // unix timestamp
 }
 
 type MinerStat struct {
@@ -50,6 +96,42 @@ type MinerStat struct {
 	LatestPunishInfo []PunishSminerData `json:"punish_info_list"`
 	TotalReward      string             `json:"total_reward"`
 	RewardIssued     string             `json:"reward_issued"`
+	// raw values in the smallest unit, collaterals, debt and rewards are in 10^-18 CESS
+	CollateralsRaw  string `json:"collaterals_raw"`
+	DebtRaw         string `json:"debt_raw"`
+	TotalRewardRaw  string `json:"total_reward_raw"`
+	RewardIssuedRaw string `json:"reward_issued_raw"`
+	// unit: byte
+	DeclarationSpaceBytes uint64 `json:"declaration_space_bytes"`
+	IdleSpaceBytes        uint64 `json:"idle_space_bytes"`
+	ServiceSpaceBytes     uint64 `json:"service_space_bytes"`
+	LockSpaceBytes        uint64 `json:"lock_space_bytes"`
+	// balances of the signature, staking and earnings accounts
+	Balances []AccountBalance `json:"balances"`
+	// the current challenge of the miner, nil if the snapshot could not be queried
+	Challenge *ChallengeStatus `json:"challenge,omitempty"`
+}
+
+// ChallengeStatus is the state of the current challenge of a miner, deadlines are block numbers
+type ChallengeStatus struct {
+	Challenged        bool   `json:"challenged"` // false if the miner is not in the current challenge snapshot
+	Start             uint32 `json:"start"`
+	IdleDeadline      uint32 `json:"idle_deadline"`
+	ServiceDeadline   uint32 `json:"service_deadline"`
+	VerifyDeadline    uint32 `json:"verify_deadline"` // the tee verifies the submitted proofs before this block
+	IdleSubmitted     bool   `json:"idle_submitted"`
+	ServiceSubmitted  bool   `json:"service_submitted"`
+	IdleBlocksLeft    int64  `json:"idle_blocks_left"` // negative if the deadline has passed
+	ServiceBlocksLeft int64  `json:"service_blocks_left"`
+	BlockId           uint32 `json:"block_id"` // the block the snapshot was queried at
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+type MinerChallenge struct {
+	Host         string `json:"host"`
+	SignatureAcc string `json:"signature_acc"`
+	Name         string `json:"name"`
+	ChallengeStatus
 }
 
 type MinerConfigFile struct {
@@ -78,18 +160,90 @@ type YamlConfig struct {
 	External       bool       `yaml:"external" json:"external"`
 	Port           int        `yaml:"port" json:"port"`
 	Hosts          []HostItem `yaml:"hosts" json:"hosts"`
-	ScrapeInterval int        `yaml:"scrapeInterval" json:"scrapeInterval"`
-	Alert          struct {
+	ScrapeInterval int        `yaml:"scrapeInterval" json:"scrapeInterval"` // the interval of the chain queries, schedules.chain takes priority
+	// Schedules are the intervals of the independent tasks of each host
+	Schedules ScheduleConfig `yaml:"schedules,omitempty" json:"schedules,omitempty"`
+	// ChainQuery bounds the chain queries of all hosts, which share one connection to the rpc
+	ChainQuery struct {
+		Workers int `yaml:"workers,omitempty" json:"workers,omitempty"` // queries run at the same time
+		QPS     int `yaml:"qps,omitempty" json:"qps,omitempty"`         // queries per second
+	} `yaml:"chain_query,omitempty" json:"chain_query,omitempty"`
+	// Selectors select the monitored containers of the hosts without their own selectors, defaults to the miner image
+	Selectors []ContainerSelector `yaml:"selectors,omitempty" json:"selectors,omitempty"`
+	Alert     struct {
 		Enable  bool     `yaml:"enable" json:"enable"`
 		Webhook []string `yaml:"webhook,omitempty" json:"webhook,omitempty"`
-		Email   struct {
-			SmtpEndpoint string   `yaml:"smtp_endpoint,omitempty" json:"smtp_endpoint,omitempty"`
-			SmtpPort     int      `yaml:"smtp_port,omitempty" json:"smtp_port,omitempty"`
-			SenderAddr   string   `yaml:"smtp_account,omitempty" json:"smtp_account,omitempty"`
-			SmtpPassword string   `yaml:"smtp_password,omitempty" json:"smtp_password,omitempty"`
-			Receiver     []string `yaml:"receiver,omitempty" json:"receiver,omitempty"`
+		// NamedWebhooks can be referenced by name in routes
+		NamedWebhooks []NamedWebhook `yaml:"named_webhooks,omitempty" json:"named_webhooks,omitempty"`
+		Email         struct {
+			SmtpEndpoint string `yaml:"smtp_endpoint,omitempty" json:"smtp_endpoint,omitempty"`
+			SmtpPort     int    `yaml:"smtp_port,omitempty" json:"smtp_port,omitempty"`
+			// TLSMode is starttls, tls or none, defaults to tls on port 465 and starttls otherwise
+			TLSMode      string `yaml:"tls_mode,omitempty" json:"tls_mode,omitempty"`
+			SenderAddr   string `yaml:"smtp_account,omitempty" json:"smtp_account,omitempty"`   // login username
+			SmtpPassword string `yaml:"smtp_password,omitempty" json:"smtp_password,omitempty"` // no authentication if empty
+			From         string `yaml:"from,omitempty" json:"from,omitempty"`                   // defaults to smtp_account
+			// Subject is a go template executed with the alert content, e.g. [{{.Severity}}] alert on {{.HostIp}}
+			Subject  string   `yaml:"subject,omitempty" json:"subject,omitempty"`
+			Receiver []string `yaml:"receiver,omitempty" json:"receiver,omitempty"`
+			Cc       []string `yaml:"cc,omitempty" json:"cc,omitempty"`
+			Bcc      []string `yaml:"bcc,omitempty" json:"bcc,omitempty"`
 		} `yaml:"email"`
+		PagerDuty struct {
+			RoutingKey string `yaml:"routing_key,omitempty" json:"routing_key,omitempty"` // Events API v2 integration key
+			EventsURL  string `yaml:"events_url,omitempty" json:"events_url,omitempty"`
+		} `yaml:"pagerduty,omitempty" json:"pagerduty,omitempty"`
+		Opsgenie struct {
+			ApiKey string `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+			ApiURL string `yaml:"api_url,omitempty" json:"api_url,omitempty"` // https://api.opsgenie.com or https://api.eu.opsgenie.com
+		} `yaml:"opsgenie,omitempty" json:"opsgenie,omitempty"`
+		Delivery struct {
+			MaxAttempts    int            `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+			InitialBackoff int            `yaml:"initial_backoff,omitempty" json:"initial_backoff,omitempty"` // unit: second
+			MaxBackoff     int            `yaml:"max_backoff,omitempty" json:"max_backoff,omitempty"`         // unit: second
+			RateLimits     map[string]int `yaml:"rate_limits,omitempty" json:"rate_limits,omitempty"`         // messages per minute by channel type
+		} `yaml:"delivery,omitempty" json:"delivery,omitempty"`
+		// RepeatInterval is how long an active alert waits before it is sent again while the condition lasts, unit: minute
+		RepeatInterval     int                 `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+		MaintenanceWindows []MaintenanceWindow `yaml:"maintenance_windows,omitempty" json:"maintenance_windows,omitempty"`
+		Routes             []AlertRoute        `yaml:"routes,omitempty" json:"routes,omitempty"`
+		// EventRules alert if a positive miner has no expected chain event for a while, e.g. no idle proof submitted
+		EventRules []EventRule `yaml:"event_rules,omitempty" json:"event_rules,omitempty"`
+		// DefaultReceivers get the alerts no route matched, empty means all channels
+		DefaultReceivers []string `yaml:"default_receivers,omitempty" json:"default_receivers,omitempty"`
+		Thresholds       struct {
+			MinCollateral     string `yaml:"min_collateral,omitempty" json:"min_collateral,omitempty"`         // unit: CESS, disabled if empty
+			CollateralPerTiB  string `yaml:"collateral_per_tib,omitempty" json:"collateral_per_tib,omitempty"` // unit: CESS, collateral required by each TiB of declared space, disabled if empty
+			RewardStallEras   int    `yaml:"reward_stall_eras,omitempty" json:"reward_stall_eras,omitempty"`   // alert if reward issued does not increase for this many eras, disabled if 0
+			SpaceFullDays     int    `yaml:"space_full_days,omitempty" json:"space_full_days,omitempty"`       // alert if idle space is projected to run out within this many days, disabled if 0
+			MinFeeBalance     string `yaml:"min_fee_balance,omitempty" json:"min_fee_balance,omitempty"`       // unit: CESS, alert if the free balance of the signature account is lower
+			RpcMaxLag         int    `yaml:"rpc_max_lag,omitempty" json:"rpc_max_lag,omitempty"`               // unit: block, alert if a rpc of a miner lags behind the reference chain more
+			DiskUsagePercent  int    `yaml:"disk_usage_percent,omitempty" json:"disk_usage_percent,omitempty"` // alert if the filesystem of a miner workspace is fuller
+			InodeUsagePercent int    `yaml:"inode_usage_percent,omitempty" json:"inode_usage_percent,omitempty"`
+			DiskFullHours     int    `yaml:"disk_full_hours,omitempty" json:"disk_full_hours,omitempty"`         // alert if the workspace is projected to be full within this many hours
+			ChainMaxLag       int    `yaml:"chain_max_lag,omitempty" json:"chain_max_lag,omitempty"`             // unit: block, alert if a chain node lags behind the chain query rpc more
+			ChainStallMinutes int    `yaml:"chain_stall_minutes,omitempty" json:"chain_stall_minutes,omitempty"` // alert if the best block of a chain node does not increase for this many minutes
+			ChainFinalityLag  int    `yaml:"chain_finality_lag,omitempty" json:"chain_finality_lag,omitempty"`   // unit: block, alert if the finalized block falls behind the best block more
+			// unit: block, alert if an idle or service proof is still not submitted this close to its deadline
+			ChallengeWarnBlocks int `yaml:"challenge_warn_blocks,omitempty" json:"challenge_warn_blocks,omitempty"`
+		} `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
 	} `yaml:"alert" json:"alert"`
+	Report ReportConfig `yaml:"report,omitempty" json:"report,omitempty"`
+	Probe  struct {
+		// Mode is auto, exec or direct, auto probes from watchdog for local hosts and inside the miner container for remote hosts
+		Mode    string `yaml:"mode,omitempty" json:"mode,omitempty"`
+		Timeout int    `yaml:"timeout,omitempty" json:"timeout,omitempty"` // unit: second
+	} `yaml:"probe,omitempty" json:"probe,omitempty"`
+	Version struct {
+		// Expected is the image tag (e.g. testnet) or digest (sha256:...) miners should run, empty to disable
+		Expected string `yaml:"expected,omitempty" json:"expected,omitempty"`
+		// CheckLocal alerts if the image tag on the host has been pulled to another image than a miner runs
+		CheckLocal bool `yaml:"check_local,omitempty" json:"check_local,omitempty"`
+	} `yaml:"version,omitempty" json:"version,omitempty"`
+	Uptime struct {
+		Windows []int   `yaml:"windows,omitempty" json:"windows,omitempty"` // unit: day, default 1, 7 and 30
+		Target  float64 `yaml:"target,omitempty" json:"target,omitempty"`   // sla target in percent
+	} `yaml:"uptime,omitempty" json:"uptime,omitempty"`
 	Auth struct {
 		Username     string `yaml:"username" json:"enable"`
 		Password     string `yaml:"password" json:"password"`
@@ -98,6 +252,157 @@ type YamlConfig struct {
 	} `yaml:"auth"`
 }
 
+type AlertJob struct {
+	ID          string       `json:"id"`
+	AlertID     string       `json:"alert_id"` // id of the alert record in history
+	Channel     string       `json:"channel"`  // email, pagerduty, opsgenie or <webhook type>-<url hash>
+	Action      string       `json:"action"`   // trigger or resolve
+	Content     AlertContent `json:"content"`
+	Receivers   []string     `json:"receivers,omitempty"` // email receivers left to retry, empty means all
+	Attempts    int          `json:"attempts"`
+	NextAttempt int64        `json:"next_attempt"` // unix timestamp
+	LastError   string       `json:"last_error,omitempty"`
+	CreatedAt   int64        `json:"created_at"`
+	UpdatedAt   int64        `json:"updated_at"`
+}
+
+type AlertRecord struct {
+	ID         string          `json:"id"`
+	Action     string          `json:"action"` // trigger or resolve
+	Content    AlertContent    `json:"content"`
+	Status     string          `json:"status"`                // pending, delivered, partial, failed, muted, silenced
+	SilencedBy string          `json:"silenced_by,omitempty"` // silence id or maintenance window name
+	Deliveries []AlertDelivery `json:"deliveries"`
+	CreatedAt  int64           `json:"created_at"`
+	UpdatedAt  int64           `json:"updated_at"`
+}
+
+type AlertDelivery struct {
+	Channel     string `json:"channel"`
+	Status      string `json:"status"` // pending, delivered, failed
+	Attempts    int    `json:"attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	DeliveredAt int64  `json:"delivered_at,omitempty"`
+}
+
+// ChannelTestResult is the result of sending a test alert to one channel
+type ChannelTestResult struct {
+	Channel    string `json:"channel"`
+	Type       string `json:"type"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"` // http status code, 0 for email or if no response received
+	Error      string `json:"error,omitempty"`
+	Body       string `json:"body,omitempty"` // response body of a failed request
+}
+
+type AlertRecordList struct {
+	Content []AlertRecord `json:"content"`
+	Count   int           `json:"count"`
+}
+
+type AlertRecordFilter struct {
+	Host     string
+	Account  string
+	Severity string
+	Kind     string
+	Status   string
+	From     int64 // unix timestamp, 0 means no limit
+	To       int64
+	Page     int
+	PageSize int
+}
+
+// AlertMatcher matches a label of an alert: host, group, account, container, kind or severity
+type AlertMatcher struct {
+	Name    string `yaml:"name" json:"name"`
+	Value   string `yaml:"value" json:"value"`
+	IsRegex bool   `yaml:"is_regex,omitempty" json:"is_regex"` // the regex is anchored at both ends
+}
+
+type Silence struct {
+	ID        string         `json:"id"`
+	Matchers  []AlertMatcher `json:"matchers"`
+	StartsAt  int64          `json:"starts_at"` // unix timestamp, 0 means now
+	EndsAt    int64          `json:"ends_at"`
+	CreatedBy string         `json:"created_by"`
+	Comment   string         `json:"comment"`
+	CreatedAt int64          `json:"created_at"`
+}
+
+type NamedWebhook struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+}
+
+// AlertRoute sends the matched alerts to its receivers, routes are evaluated in order and
+// the evaluation stops at the first matched route unless Continue is set
+type AlertRoute struct {
+	Name      string         `yaml:"name,omitempty" json:"name,omitempty"`
+	Matchers  []AlertMatcher `yaml:"matchers,omitempty" json:"matchers,omitempty"` // empty matches all alerts
+	Receivers []string       `yaml:"receivers" json:"receivers"`                   // channel name or type, e.g. email, pagerduty, slack, my-named-webhook
+	Continue  bool           `yaml:"continue,omitempty" json:"continue,omitempty"`
+}
+
+// EventRule expects a kind of chain event from every positive miner within a period
+type EventRule struct {
+	Name   string `yaml:"name" json:"name"`
+	Event  string `yaml:"event" json:"event"`   // kind of the miner event, e.g. idle_proof
+	Within int    `yaml:"within" json:"within"` // unit: minute
+}
+
+// MaintenanceWindow silences the matched alerts at a recurring time
+type MaintenanceWindow struct {
+	Name     string         `yaml:"name" json:"name"`
+	Matchers []AlertMatcher `yaml:"matchers,omitempty" json:"matchers,omitempty"` // empty matches all alerts
+	Weekdays []string       `yaml:"weekdays,omitempty" json:"weekdays,omitempty"` // mon, tue, wed, thu, fri, sat, sun, empty means every day
+	Start    string         `yaml:"start" json:"start"`                           // local time, 15:04
+	Duration int            `yaml:"duration" json:"duration"`                     // unit: minute
+	Comment  string         `yaml:"comment,omitempty" json:"comment,omitempty"`
+}
+
+type ReportConfig struct {
+	Enable    bool     `yaml:"enable" json:"enable"`
+	Schedule  string   `yaml:"schedule,omitempty" json:"schedule,omitempty"`   // daily or weekly
+	Time      string   `yaml:"time,omitempty" json:"time,omitempty"`           // local time, 15:04
+	Weekday   string   `yaml:"weekday,omitempty" json:"weekday,omitempty"`     // mon, tue ... for weekly report
+	Receivers []string `yaml:"receivers,omitempty" json:"receivers,omitempty"` // email, named webhook or webhook type, empty means all
+}
+
+// Report is a summary of all hosts and miners in a period
+type Report struct {
+	Title       string         `json:"title"`
+	PeriodStart string         `json:"period_start"`
+	PeriodEnd   string         `json:"period_end"`
+	TotalAlerts int            `json:"total_alerts"`
+	AlertCounts map[string]int `json:"alert_counts"` // key: severity
+	Hosts       []HostReport   `json:"hosts"`
+}
+
+type HostReport struct {
+	Host   string        `json:"host"`
+	Uptime string        `json:"uptime"` // in the period, - if not observed
+	Alerts int           `json:"alerts"`
+	Miners []MinerReport `json:"miners"`
+}
+
+type MinerReport struct {
+	Name              string `json:"name"`
+	SignatureAcc      string `json:"signature_acc"`
+	Status            string `json:"status"`
+	ContainerState    string `json:"container_state"`
+	Uptime            string `json:"uptime"` // in the period, - if not observed
+	DeclarationSpace  string `json:"declaration_space"`
+	IdleSpace         string `json:"idle_space"`
+	PrevIdleSpace     string `json:"prev_idle_space"` // at the last report
+	ServiceSpace      string `json:"service_space"`
+	PrevServiceSpace  string `json:"prev_service_space"`
+	TotalReward       string `json:"total_reward"`
+	RewardAccrued     string `json:"reward_accrued"` // since the last report
+	Punishments       int    `json:"punishments"`
+	ContainerRestarts int    `json:"container_restarts"`
+	Alerts            int    `json:"alerts"`
+}
+
 type AlertToggle struct {
 	Status bool `name:"enable"`
 }
@@ -123,4 +428,304 @@ type PunishSminerData struct {
 	Amount        string `json:"amount"`
 	Type          uint8  `json:"type"` // 1:not submit service proof 2:service proof result is false
 	Timestamp     int64  `json:"timestamp"`
+	// NoSubmitIdleProof, NoSubmitSvcProof, IdleProofResIncorrect, SvcProofResIncorrect or the extrinsic name if unknown
+	Reason string `json:"reason"`
+}
+
+// PunishmentRecord is a persisted punishment of a monitored miner
+type PunishmentRecord struct {
+	PunishSminerData
+	Host string `json:"host"`
+	Time int64  `json:"time"` // unix timestamp of the block
+}
+
+type PunishmentRecordList struct {
+	Content []PunishmentRecord `json:"content"`
+	Count   int                `json:"count"`
+}
+
+type PunishmentFilter struct {
+	Host     string
+	Account  string
+	Reason   string // empty means any reason
+	From     int64  // unix timestamp, 0 means no limit
+	To       int64
+	Page     int
+	PageSize int
+}
+
+// PunishmentWindow counts the punishments of a miner in a time window
+type PunishmentWindow struct {
+	Count          int            `json:"count"`
+	NoProof        int            `json:"no_proof"`        // no idle or service proof submitted
+	IncorrectProof int            `json:"incorrect_proof"` // incorrect idle or service proof
+	Reasons        map[string]int `json:"reasons"`         // count by punishment reason
+	Slashed        string         `json:"slashed"`         // unit: CESS
+	SlashedRaw     string         `json:"slashed_raw"`     // unit: 10^-18 CESS
+}
+
+type PunishmentStats struct {
+	Host           string           `json:"host"`
+	SignatureAcc   string           `json:"signature_acc"`
+	Name           string           `json:"name"`
+	Last24h        PunishmentWindow `json:"last_24h"`
+	Last7d         PunishmentWindow `json:"last_7d"`
+	Last30d        PunishmentWindow `json:"last_30d"`
+	LastPunishedAt int64            `json:"last_punished_at"` // unix timestamp, 0 if never punished
+}
+
+// MinerEvent is a chain event of a monitored miner
+type MinerEvent struct {
+	Host          string `json:"host"`
+	SignatureAcc  string `json:"signature_acc"`
+	Kind          string `json:"kind"` // registration, exit, withdraw, idle_proof, service_proof, tag_certification, reward_claim, collateral, frozen, unfrozen or punishment
+	BlockId       uint32 `json:"block_id"`
+	BlockHash     string `json:"block_hash,omitempty"`
+	ExtrinsicHash string `json:"extrinsic_hash,omitempty"`
+	ExtrinsicName string `json:"extrinsic_name,omitempty"`
+	Success       bool   `json:"success"`
+	Detail        string `json:"detail,omitempty"`
+	Time          int64  `json:"time"` // unix timestamp of the block
+}
+
+type MinerEventList struct {
+	Content []MinerEvent `json:"content"`
+	Count   int          `json:"count"`
+}
+
+type MinerEventFilter struct {
+	Host     string
+	Account  string
+	Kind     string
+	From     int64 // unix timestamp, 0 means no limit
+	To       int64
+	Page     int
+	PageSize int
+}
+
+// StatusTransition is a change of the status of a miner on chain
+type StatusTransition struct {
+	Host         string `json:"host"`
+	SignatureAcc string `json:"signature_acc"`
+	From         string `json:"from"` // empty for the first observed status
+	To           string `json:"to"`
+	BlockId      uint32 `json:"block_id"`
+	Time         int64  `json:"time"`    // unix timestamp when the transition was observed
+	Alerted      bool   `json:"alerted"` // a transition to a non positive status is alerted once
+}
+
+// StatusTimeline is the status history of a miner in a time window
+type StatusTimeline struct {
+	Host         string             `json:"host"`
+	SignatureAcc string             `json:"signature_acc"`
+	Name         string             `json:"name"`
+	Current      string             `json:"current"`
+	Since        int64              `json:"since"` // unix timestamp when the current status was observed first
+	From         int64              `json:"from"`
+	To           int64              `json:"to"`
+	Transitions  []StatusTransition `json:"transitions,omitempty"`
+	TimeInStatus map[string]int64   `json:"time_in_status"`         // unit: second
+	Percent      map[string]float64 `json:"time_in_status_percent"` // of the observed time in the window
+}
+
+// UptimeSample is the availability of a host or a miner observed by a scrape
+type UptimeSample struct {
+	Time     int64 `json:"time"`
+	Up       bool  `json:"up"` // docker reachable for a host, running, positive and not punished for a miner
+	Running  bool  `json:"running,omitempty"`
+	Positive bool  `json:"positive,omitempty"`
+	Punished bool  `json:"punished,omitempty"`
+}
+
+// UptimeWindow is the availability in the last days, unit: percent
+type UptimeWindow struct {
+	Days        int     `json:"days"`
+	Uptime      float64 `json:"uptime"`
+	Running     float64 `json:"running,omitempty"`
+	Positive    float64 `json:"positive,omitempty"`
+	Unpunished  float64 `json:"unpunished,omitempty"`
+	Samples     int     `json:"samples"`
+	Observed    int64   `json:"observed"` // unit: second, the time covered by the samples
+	MeetsTarget bool    `json:"meets_target"`
+}
+
+type HostUptime struct {
+	Host    string         `json:"host"`
+	Windows []UptimeWindow `json:"windows"`
+}
+
+type MinerUptime struct {
+	Host         string         `json:"host"`
+	SignatureAcc string         `json:"signature_acc"`
+	Name         string         `json:"name"`
+	Windows      []UptimeWindow `json:"windows"`
+}
+
+type UptimeReport struct {
+	Target float64       `json:"target"`
+	Hosts  []HostUptime  `json:"hosts"`
+	Miners []MinerUptime `json:"miners"`
+}
+
+// SpaceSample is the space of a miner at a time, unit: byte
+type SpaceSample struct {
+	Time        int64  `json:"time"` // unix timestamp
+	Declaration uint64 `json:"declaration"`
+	Idle        uint64 `json:"idle"`
+	Service     uint64 `json:"service"`
+	Lock        uint64 `json:"lock"`
+}
+
+// SpaceForecast is the projection of a miner's space from the samples in the forecast window
+type SpaceForecast struct {
+	Host             string  `json:"host"`
+	Name             string  `json:"name"`
+	SignatureAcc     string  `json:"signature_acc"`
+	DeclarationSpace uint64  `json:"declaration_space"` // unit: byte
+	IdleSpace        uint64  `json:"idle_space"`
+	ServiceSpace     uint64  `json:"service_space"`
+	LockSpace        uint64  `json:"lock_space"`
+	FillRate         float64 `json:"fill_rate"`    // service space growth, unit: byte/day
+	IdleRate         float64 `json:"idle_rate"`    // idle space change, unit: byte/day
+	CertifyRate      float64 `json:"certify_rate"` // certified (idle + service) space growth, unit: byte/day
+	// unix timestamp, 0 if the space is not projected to run out / be certified with the current trend
+	IdleExhaustedAt  int64 `json:"idle_exhausted_at"`
+	FullyCertifiedAt int64 `json:"fully_certified_at"`
+	Samples          int   `json:"samples"`
+	UpdatedAt        int64 `json:"updated_at"`
+}
+
+type AccountBalance struct {
+	Role        string `json:"role"` // signature, staking or earnings
+	Account     string `json:"account"`
+	Free        string `json:"free"` // unit: CESS
+	Reserved    string `json:"reserved"`
+	FreeRaw     string `json:"free_raw"` // unit: 10^-18 CESS
+	ReservedRaw string `json:"reserved_raw"`
+	// inflows into the account (the sum of the balance increases), only for the earnings account, unit: CESS
+	Inflow24h string `json:"inflow_24h,omitempty"`
+	Inflow7d  string `json:"inflow_7d,omitempty"`
+	Inflow30d string `json:"inflow_30d,omitempty"`
+}
+
+// BalanceSample is the balance of an account at a block, unit: 10^-18 CESS
+type BalanceSample struct {
+	Time     int64  `json:"time"` // unix timestamp
+	Block    uint32 `json:"block"`
+	Free     string `json:"free"`
+	Reserved string `json:"reserved"`
+}
+
+// ScheduleConfig is the interval of each task of the watchdog clients, unit: second
+type ScheduleConfig struct {
+	Discovery int `yaml:"discovery,omitempty" json:"discovery,omitempty"` // list the containers, register the miners and components
+	Stats     int `yaml:"stats,omitempty" json:"stats,omitempty"`         // container resource stats
+	Chain     int `yaml:"chain,omitempty" json:"chain,omitempty"`         // on-chain queries of each miner
+	Probe     int `yaml:"probe,omitempty" json:"probe,omitempty"`         // health probes of the tees, miner ports, rpcs and chain nodes, and workspace usage
+	// Jitter randomly shortens or extends each interval by up to this percent, 0 disables it and nil means the default
+	Jitter *int `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+}
+
+// ScheduledTask is the state of a periodic task of a host
+type ScheduledTask struct {
+	Host         string `json:"host"`
+	Task         string `json:"task"`
+	Interval     int    `json:"interval"` // unit: second
+	Running      bool   `json:"running"`
+	Runs         uint64 `json:"runs"`
+	LastRun      int64  `json:"last_run"`
+	LastDuration int64  `json:"last_duration"` // unit: millisecond
+	NextRun      int64  `json:"next_run"`
+}
+
+// EndpointProbe is the reachability of an endpoint from a monitored host
+type EndpointProbe struct {
+	Endpoint      string `json:"endpoint"`
+	Healthy       bool   `json:"healthy"`
+	LatencyMs     int64  `json:"latency_ms"`
+	LastError     string `json:"last_error,omitempty"`
+	LastCheck     int64  `json:"last_check"`   // unix timestamp
+	LastSuccess   int64  `json:"last_success"` // unix timestamp
+	Failures      int    `json:"failures"`     // consecutive failures
+	TotalChecks   int    `json:"total_checks"`
+	TotalFailures int    `json:"total_failures"`
+}
+
+// RpcProbe is the reachability and block height of a rpc node used by a miner
+type RpcProbe struct {
+	EndpointProbe
+	BlockHeight uint64 `json:"block_height"`
+	Lag         int64  `json:"lag"` // blocks behind the reference chain client
+}
+
+// ChainNodeStatus is the health and sync state of a chain node
+type ChainNodeStatus struct {
+	EndpointProbe
+	Syncing        bool     `json:"syncing"`
+	Peers          int      `json:"peers"`
+	Roles          []string `json:"roles"` // Full, Authority (validator) or LightClient
+	BestBlock      uint64   `json:"best_block"`
+	FinalizedBlock uint64   `json:"finalized_block"`
+	HighestBlock   uint64   `json:"highest_block"`   // highest block known from the peers
+	ReferenceBlock uint64   `json:"reference_block"` // best block of the chain query rpc, 0 if unavailable
+	Lag            int64    `json:"lag"`             // blocks behind the chain query rpc
+	FinalityLag    int64    `json:"finality_lag"`    // blocks between the best and finalized block
+	LastProgress   int64    `json:"last_progress"`   // unix timestamp when the best block increased the last time
+}
+
+// DiskUsage is the usage of the filesystem holding a miner workspace, unit: byte
+type DiskUsage struct {
+	Path              string  `json:"path"`
+	Filesystem        string  `json:"filesystem"`
+	MountPoint        string  `json:"mount_point"` // in the container
+	Total             uint64  `json:"total"`
+	Used              uint64  `json:"used"`
+	Available         uint64  `json:"available"`
+	UsedPercent       float64 `json:"used_percent"`
+	InodesTotal       uint64  `json:"inodes_total"`
+	InodesUsed        uint64  `json:"inodes_used"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+	GrowthRate        float64 `json:"growth_rate"`   // unit: byte/day
+	MaxUseSpace       uint64  `json:"max_use_space"` // maxusespace of the miner config
+	FullAt            int64   `json:"full_at"`       // projected unix timestamp, 0 if not growing
+	UpdatedAt         int64   `json:"updated_at"`
+}
+
+// ImageVersion is the image a miner container runs
+type ImageVersion struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"` // repo digest sha256:..., empty if the image was built locally
+	ImageID    string `json:"image_id"`
+	LatestID   string `json:"latest_id,omitempty"` // id of the image the tag points to on the host
+	Outdated   bool   `json:"outdated"`            // the tag has been pulled to another image than the container runs
+	Unexpected bool   `json:"unexpected"`          // the tag or digest differs from the expected version
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// VersionGroup is the miners running the same image
+type VersionGroup struct {
+	Repository string          `json:"repository"`
+	Tag        string          `json:"tag"`
+	Digest     string          `json:"digest"`
+	ImageID    string          `json:"image_id"`
+	Count      int             `json:"count"`
+	Miners     []VersionMember `json:"miners"`
+}
+
+type VersionMember struct {
+	Host         string `json:"host"`
+	Name         string `json:"name"`
+	SignatureAcc string `json:"signature_acc"`
+	Outdated     bool   `json:"outdated"`
+	Unexpected   bool   `json:"unexpected"`
+}
+
+// FleetVersions is the version distribution of the miners of all monitored hosts
+type FleetVersions struct {
+	Expected   string         `json:"expected,omitempty"`
+	Total      int            `json:"total"`
+	Outdated   int            `json:"outdated"`
+	Unexpected int            `json:"unexpected"`
+	Versions   []VersionGroup `json:"versions"` // ordered by count
 }
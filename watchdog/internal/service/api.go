@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/core"
@@ -15,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -70,7 +72,7 @@ func getClientsStatus(c *gin.Context) {
 	res := make(map[string]string, len(core.Clients))
 	for _, client := range core.Clients {
 		status := "Sleeping"
-		if client.Updating {
+		if client.IsUpdating() {
 			status = "Running"
 		}
 		res[client.Host] = status
@@ -78,6 +80,21 @@ func getClientsStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// watchdog godoc
+// @Description  Get the state of the scheduled tasks of the hosts: discovery, stats, probe and chain
+// @Tags         Get Clients Status
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {array} model.ScheduledTask
+// @Router       /schedules [get]
+func getSchedules(c *gin.Context) {
+	if core.GlobalScheduler == nil {
+		c.JSON(http.StatusOK, []model.ScheduledTask{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalScheduler.Tasks(c.Query("host")))
+}
+
 // watchdog godoc
 // @Description  Update watchdog configuration
 // @Tags         Update Config
@@ -88,7 +105,15 @@ func getClientsStatus(c *gin.Context) {
 // @Router       /config [post]
 func setConfig(c *gin.Context) {
 	var newConfig model.YamlConfig
-	if err := c.ShouldBindJSON(&newConfig); err != nil {
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, &newConfig)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = util.KeepOmittedConfig(body, &newConfig, core.CustomConfig); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -98,15 +123,17 @@ func setConfig(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Failed to load config file from %s", constant.ConfPath)})
 		return
 	}
-	// remove old config
-	util.RemoveFields(configTemp, "hosts", "scrapeInterval", "alert")
-
 	// do not leak acc/password in unsafe(http without tls) network (keep acc/password as original conf)
 	newConfig.Alert.Email.SenderAddr = core.CustomConfig.Alert.Email.SenderAddr
 	newConfig.Alert.Email.SmtpPassword = core.CustomConfig.Alert.Email.SmtpPassword
+	newConfig.Alert.PagerDuty.RoutingKey = core.CustomConfig.Alert.PagerDuty.RoutingKey
+	newConfig.Alert.Opsgenie.ApiKey = core.CustomConfig.Alert.Opsgenie.ApiKey
 
-	// add new config
-	util.AddFields(configTemp, newConfig)
+	// only write the sections sent, the others keep their value in the file
+	if err = util.MergeConfig(configTemp, body, newConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	err = util.SaveConfigFile(constant.ConfPath, configTemp)
 	if err != nil {
 		log.Logger.Errorf("Failed to save file to: %v", constant.ConfPath)
@@ -140,11 +167,24 @@ func getConfig(c *gin.Context) {
 	for i := 0; i < len(conf.Alert.Webhook); i++ {
 		conf.Alert.Webhook[i] = splitURLByTopLevelDomain(conf.Alert.Webhook[i])
 	}
-	for i := 0; i < len(conf.Alert.Email.Receiver); i++ {
-		conf.Alert.Email.Receiver[i] = replaceFirstThreeChars(conf.Alert.Email.Receiver[i])
+	conf.Alert.NamedWebhooks = append([]model.NamedWebhook(nil), conf.Alert.NamedWebhooks...)
+	for i := 0; i < len(conf.Alert.NamedWebhooks); i++ {
+		conf.Alert.NamedWebhooks[i].URL = splitURLByTopLevelDomain(conf.Alert.NamedWebhooks[i].URL)
 	}
+	conf.Alert.Email.Receiver = maskAddresses(conf.Alert.Email.Receiver)
+	conf.Alert.Email.Cc = maskAddresses(conf.Alert.Email.Cc)
+	conf.Alert.Email.Bcc = maskAddresses(conf.Alert.Email.Bcc)
 	conf.Alert.Email.SenderAddr = replaceFirstThreeChars(conf.Alert.Email.SenderAddr)
+	if conf.Alert.Email.From != "" {
+		conf.Alert.Email.From = replaceFirstThreeChars(conf.Alert.Email.From)
+	}
 	conf.Alert.Email.SmtpPassword = "******"
+	if conf.Alert.PagerDuty.RoutingKey != "" {
+		conf.Alert.PagerDuty.RoutingKey = "******"
+	}
+	if conf.Alert.Opsgenie.ApiKey != "" {
+		conf.Alert.Opsgenie.ApiKey = "******"
+	}
 	conf.Auth.Password = "******"
 	conf.Auth.JWTSecretKey = "******"
 	c.JSON(http.StatusOK, conf)
@@ -194,6 +234,444 @@ func setAlertToggle(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "updateConfig alert status success"})
 }
 
+// watchdog godoc
+// @Description  List alert history
+// @Tags         Alert History
+// @Produce      json
+// @Param        host       query  string  false  "Host IP"
+// @Param        account    query  string  false  "Signature account"
+// @Param        severity   query  string  false  "critical, error, warning or info"
+// @Param        kind       query  string  false  "Alert kind"
+// @Param        status     query  string  false  "Delivery status: pending, delivered, partial, failed or muted"
+// @Param        from       query  string  false  "Start time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        to         query  string  false  "End time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        page       query  int     false  "Page number, start from 1"
+// @Param        page_size  query  int     false  "Page size, default 20, max 500"
+// @Success      200 {object} model.AlertRecordList
+// @Router       /alerts [get]
+func getAlerts(c *gin.Context) {
+	if core.GlobalAlertHistory == nil {
+		c.JSON(http.StatusOK, model.AlertRecordList{Content: []model.AlertRecord{}})
+		return
+	}
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: " + err.Error()})
+		return
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: " + err.Error()})
+		return
+	}
+	page, pageSize := parsePagination(c)
+	filter := model.AlertRecordFilter{
+		Host:     c.Query("host"),
+		Account:  c.Query("account"),
+		Severity: c.Query("severity"),
+		Kind:     c.Query("kind"),
+		Status:   c.Query("status"),
+		From:     from,
+		To:       to,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	c.JSON(http.StatusOK, core.GlobalAlertHistory.Query(filter))
+}
+
+// watchdog godoc
+// @Description  List alerts waiting to be delivered
+// @Tags         Alert Queue
+// @Produce      json
+// @Success      200 {object} []model.AlertJob
+// @Router       /alerts/queue [get]
+func getAlertQueue(c *gin.Context) {
+	if core.GlobalAlertQueue == nil {
+		c.JSON(http.StatusOK, []model.AlertJob{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalAlertQueue.PendingJobs())
+}
+
+// watchdog godoc
+// @Description  List alerts which failed to be delivered after max attempts
+// @Tags         Alert Queue
+// @Produce      json
+// @Success      200 {object} []model.AlertJob
+// @Router       /alerts/dead-letter [get]
+func getDeadLetterAlerts(c *gin.Context) {
+	if core.GlobalAlertQueue == nil {
+		c.JSON(http.StatusOK, []model.AlertJob{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalAlertQueue.DeadLetterJobs())
+}
+
+// watchdog godoc
+// @Description  Put a dead-letter alert back to the delivery queue
+// @Tags         Alert Queue
+// @Produce      json
+// @Param        id   path  string  true  "Alert job ID"
+// @Success      200 {object} map[string]string
+// @Router       /alerts/dead-letter/{id}/resend [post]
+func resendDeadLetterAlert(c *gin.Context) {
+	if core.GlobalAlertQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Alert queue is not running"})
+		return
+	}
+	if err := core.GlobalAlertQueue.Resend(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "alert has been put back to the delivery queue"})
+}
+
+// watchdog godoc
+// @Description  Send a test alert to each configured channel and report the result of every channel
+// @Tags         Alert
+// @Produce      json
+// @Param        channel  query  string  false  "Only test the channel with this name or type"
+// @Success      200 {object} []model.ChannelTestResult
+// @Router       /alerts/test [post]
+func testAlertChannels(c *gin.Context) {
+	results, err := core.SendTestAlert(c.Query("channel"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// watchdog godoc
+// @Description  List silences
+// @Tags         Silence
+// @Produce      json
+// @Param        all  query  bool  false  "Include expired silences"
+// @Success      200 {object} []model.Silence
+// @Router       /silences [get]
+func getSilences(c *gin.Context) {
+	if core.GlobalSilenceManager == nil {
+		c.JSON(http.StatusOK, []model.Silence{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalSilenceManager.List(c.Query("all") == "true"))
+}
+
+// watchdog godoc
+// @Description  Create a silence, matched alerts are recorded but not delivered until it ends
+// @Tags         Silence
+// @Accept       json
+// @Produce      json
+// @Param        model.Silence body model.Silence true "Silence"
+// @Success      200 {object} model.Silence
+// @Router       /silences [post]
+func createSilence(c *gin.Context) {
+	if core.GlobalSilenceManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Silence manager is not running"})
+		return
+	}
+	var silence model.Silence
+	if err := c.ShouldBindJSON(&silence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if silence.CreatedBy == "" {
+		silence.CreatedBy = c.GetString("username")
+	}
+	res, err := core.GlobalSilenceManager.Create(silence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// watchdog godoc
+// @Description  Expire a silence
+// @Tags         Silence
+// @Produce      json
+// @Param        id   path  string  true  "Silence ID"
+// @Success      200 {object} map[string]string
+// @Router       /silences/{id} [delete]
+func expireSilence(c *gin.Context) {
+	if core.GlobalSilenceManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Silence manager is not running"})
+		return
+	}
+	if err := core.GlobalSilenceManager.Expire(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "silence expired"})
+}
+
+// watchdog godoc
+// @Description  Preview the digest report of the period since the last report
+// @Tags         Report
+// @Produce      json
+// @Success      200 {object} model.Report
+// @Router       /report [get]
+func getReport(c *gin.Context) {
+	c.JSON(http.StatusOK, core.PreviewReport())
+}
+
+// watchdog godoc
+// @Description  List the punishments of the monitored miners, the latest first
+// @Tags         Punishments
+// @Produce      json
+// @Param        host       query  string  false  "Host IP"
+// @Param        account    query  string  false  "Signature account"
+// @Param        reason     query  string  false  "NoSubmitIdleProof, NoSubmitSvcProof, IdleProofResIncorrect, SvcProofResIncorrect or an extrinsic name"
+// @Param        from       query  string  false  "Start time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        to         query  string  false  "End time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        page       query  int     false  "Page number, start from 1"
+// @Param        page_size  query  int     false  "Page size, default 20, max 500"
+// @Success      200 {object} model.PunishmentRecordList
+// @Router       /punishments [get]
+func getPunishments(c *gin.Context) {
+	if core.GlobalPunishmentHistory == nil {
+		c.JSON(http.StatusOK, model.PunishmentRecordList{Content: []model.PunishmentRecord{}})
+		return
+	}
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: " + err.Error()})
+		return
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: " + err.Error()})
+		return
+	}
+	page, pageSize := parsePagination(c)
+	filter := model.PunishmentFilter{
+		Host:     c.Query("host"),
+		Account:  c.Query("account"),
+		Reason:   c.Query("reason"),
+		From:     from,
+		To:       to,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	c.JSON(http.StatusOK, core.GlobalPunishmentHistory.Query(filter))
+}
+
+// watchdog godoc
+// @Description  Count the punishments and slashed amounts of each monitored miner in the last 24 hours, 7 and 30 days
+// @Tags         Punishments
+// @Produce      json
+// @Param        host     query  string  false  "Host IP"
+// @Param        account  query  string  false  "Signature account"
+// @Success      200 {object} []model.PunishmentStats
+// @Router       /punishments/stats [get]
+func getPunishmentStats(c *gin.Context) {
+	if core.GlobalPunishmentHistory == nil {
+		c.JSON(http.StatusOK, []model.PunishmentStats{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalPunishmentHistory.Stats(c.Query("host"), c.Query("account")))
+}
+
+// watchdog godoc
+// @Description  List the chain events of the monitored miners as a timeline, the latest first
+// @Tags         Miner Events
+// @Produce      json
+// @Param        host       query  string  false  "Host IP"
+// @Param        account    query  string  false  "Signature account"
+// @Param        kind       query  string  false  "registration, exit, withdraw, idle_proof, service_proof, tag_certification, reward_claim, collateral, frozen, unfrozen or punishment"
+// @Param        from       query  string  false  "Start time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        to         query  string  false  "End time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        page       query  int     false  "Page number, start from 1"
+// @Param        page_size  query  int     false  "Page size, default 20, max 500"
+// @Success      200 {object} model.MinerEventList
+// @Router       /events [get]
+func getMinerEvents(c *gin.Context) {
+	if core.GlobalMinerEventHistory == nil {
+		c.JSON(http.StatusOK, model.MinerEventList{Content: []model.MinerEvent{}})
+		return
+	}
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: " + err.Error()})
+		return
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: " + err.Error()})
+		return
+	}
+	page, pageSize := parsePagination(c)
+	filter := model.MinerEventFilter{
+		Host:     c.Query("host"),
+		Account:  c.Query("account"),
+		Kind:     c.Query("kind"),
+		From:     from,
+		To:       to,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	c.JSON(http.StatusOK, core.GlobalMinerEventHistory.Query(filter))
+}
+
+// watchdog godoc
+// @Description  Get the current status of each miner and the time spent in each status, default in the last 30 days
+// @Tags         Miner Status
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Param        from  query  string  false  "Start time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        to    query  string  false  "End time, unix timestamp or 2006-01-02 15:04:05"
+// @Success      200 {object} []model.StatusTimeline
+// @Router       /statuses [get]
+func getStatusTimelines(c *gin.Context) {
+	if core.GlobalMinerEventHistory == nil {
+		c.JSON(http.StatusOK, []model.StatusTimeline{})
+		return
+	}
+	from, to, ok := parseWindowParams(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalMinerEventHistory.StatusTimelines(c.Query("host"), from, to))
+}
+
+// watchdog godoc
+// @Description  Get the status transitions of a miner and the time spent in each status, default in the last 30 days
+// @Tags         Miner Status
+// @Produce      json
+// @Param        account  path   string  true   "Signature account"
+// @Param        from     query  string  false  "Start time, unix timestamp or 2006-01-02 15:04:05"
+// @Param        to       query  string  false  "End time, unix timestamp or 2006-01-02 15:04:05"
+// @Success      200 {object} model.StatusTimeline
+// @Router       /statuses/{account}/timeline [get]
+func getStatusTimeline(c *gin.Context) {
+	from, to, ok := parseWindowParams(c)
+	if !ok {
+		return
+	}
+	if core.GlobalMinerEventHistory == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No status history of the miner"})
+		return
+	}
+	timeline, found := core.GlobalMinerEventHistory.StatusTimeline(c.Param("account"), from, to)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No status history of the miner"})
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// watchdog godoc
+// @Description  Get the uptime of the hosts (docker reachable) and miners (container running, positive on chain and not punished)
+// @Description  in each configured window, compared with the sla target
+// @Tags         Uptime
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {object} model.UptimeReport
+// @Router       /uptime [get]
+func getUptime(c *gin.Context) {
+	if core.GlobalUptimeHistory == nil {
+		c.JSON(http.StatusOK, model.UptimeReport{Hosts: []model.HostUptime{}, Miners: []model.MinerUptime{}})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalUptimeHistory.Report(c.Query("host")))
+}
+
+// watchdog godoc
+// @Description  List the monitored containers of the components other than storage nodes, e.g. chain nodes and tee workers
+// @Tags         Components
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {object} []core.ComponentInfo
+// @Router       /components [get]
+func getComponents(c *gin.Context) {
+	c.JSON(http.StatusOK, core.ComponentList(c.Query("host")))
+}
+
+// watchdog godoc
+// @Description  Get the health and sync state of the chain nodes by host
+// @Tags         Components
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {object} map[string][]model.ChainNodeStatus
+// @Router       /chain-nodes [get]
+func getChainNodes(c *gin.Context) {
+	c.JSON(http.StatusOK, core.ChainNodeList(c.Query("host")))
+}
+
+// watchdog godoc
+// @Description  Get the current challenge of the miners, with the deadlines of the idle and service proofs and whether they are submitted
+// @Tags         Miners
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {array} model.MinerChallenge
+// @Router       /challenges [get]
+func getChallenges(c *gin.Context) {
+	c.JSON(http.StatusOK, core.ChallengeList(c.Query("host")))
+}
+
+// watchdog godoc
+// @Description  Group the miners by the image they run, with the miners running an outdated or unexpected image
+// @Tags         Versions
+// @Produce      json
+// @Param        host  query  string  false  "Host IP"
+// @Success      200 {object} model.FleetVersions
+// @Router       /versions [get]
+func getFleetVersions(c *gin.Context) {
+	c.JSON(http.StatusOK, core.FleetVersions(c.Query("host")))
+}
+
+// watchdog godoc
+// @Description  Forecast when the idle space of each miner runs out and when the declared space is fully certified
+// @Tags         Space Forecast
+// @Produce      json
+// @Param        host     query  string  false  "Host IP"
+// @Param        account  query  string  false  "Signature account"
+// @Success      200 {object} []model.SpaceForecast
+// @Router       /forecasts [get]
+func getSpaceForecasts(c *gin.Context) {
+	if core.GlobalSpaceHistory == nil {
+		c.JSON(http.StatusOK, []model.SpaceForecast{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalSpaceHistory.Forecasts(c.Query("host"), c.Query("account")))
+}
+
+// watchdog godoc
+// @Description  List the space samples of a miner, unit: byte
+// @Tags         Space Forecast
+// @Produce      json
+// @Param        account  path   string  true  "Signature account"
+// @Param        host     query  string  true  "Host IP"
+// @Success      200 {object} []model.SpaceSample
+// @Router       /forecasts/{account}/history [get]
+func getSpaceHistory(c *gin.Context) {
+	host := c.Query("host")
+	if host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "host is required"})
+		return
+	}
+	if core.GlobalSpaceHistory == nil {
+		c.JSON(http.StatusOK, []model.SpaceSample{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalSpaceHistory.Samples(host, c.Param("account")))
+}
+
+// watchdog godoc
+// @Description  List the balance samples of a signature, staking or earnings account, unit: 10^-18 CESS
+// @Tags         Balance
+// @Produce      json
+// @Param        account  path  string  true  "Account"
+// @Success      200 {object} []model.BalanceSample
+// @Router       /balances/{account}/history [get]
+func getBalanceHistory(c *gin.Context) {
+	if core.GlobalBalanceHistory == nil {
+		c.JSON(http.StatusOK, []model.BalanceSample{})
+		return
+	}
+	c.JSON(http.StatusOK, core.GlobalBalanceHistory.Samples(c.Param("account")))
+}
+
 type HostInfoVO struct {
 	Host          string
 	MinerInfoList []core.MinerInfo
@@ -254,6 +732,62 @@ func getMinersListByClientInfo(minerMap map[string]*core.MinerInfo) []core.Miner
 	return minerInfoArray
 }
 
+// parseWindowParams parses the from and to query params, which default to the last 30 days,
+// a bad request is responded if they are invalid
+func parseWindowParams(c *gin.Context) (int64, int64, bool) {
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: " + err.Error()})
+		return 0, 0, false
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: " + err.Error()})
+		return 0, 0, false
+	}
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if from == 0 {
+		from = time.Unix(to, 0).AddDate(0, 0, -30).Unix()
+	}
+	if from >= to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be earlier than to"})
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// parseTimeParam accepts a unix timestamp or a time in constant.TimeFormat, empty means no limit
+func parseTimeParam(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.ParseInLocation(constant.TimeFormat, value, time.Local)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 500 {
+		pageSize = 500
+	}
+	return page, pageSize
+}
+
 func replaceFirstThreeChars(s string) string {
 	// 123456@cess.network -> ***456@cess.network
 	if len(s) < 5 {
@@ -262,6 +796,18 @@ func replaceFirstThreeChars(s string) string {
 	return "***" + s[3:]
 }
 
+// maskAddresses returns a masked copy, the slice is shared with the running config
+func maskAddresses(addresses []string) []string {
+	if len(addresses) == 0 {
+		return addresses
+	}
+	res := make([]string, len(addresses))
+	for i, addr := range addresses {
+		res[i] = replaceFirstThreeChars(addr)
+	}
+	return res
+}
+
 func splitURLByTopLevelDomain(inputURL string) string {
 	// https://example.com/bot/v2/hook/4bb9bfc7-dat4-41g9-962d-d8b4c139f37c -> https://example.com/***
 	parsedURL, err := url.Parse(inputURL)
@@ -281,7 +827,7 @@ func splitURLByTopLevelDomain(inputURL string) string {
 
 func runWithNewConf(ctx context.Context) {
 	for key := range core.Clients {
-		core.Clients[key].Active = false
+		core.Clients[key].Stop()
 	}
 
 	const maxRetries = 600
@@ -313,8 +859,11 @@ func reloadConfiguration() error {
 		return fmt.Errorf("failed to init watchdog config: %w", err)
 	}
 
+	core.InitChainQueryService()
 	core.InitSmtpConfig()
 	core.InitWebhookConfig()
+	core.InitIncidentConfig()
+	core.InitAlertChannels()
 
 	if err := core.InitWatchdogClients(core.CustomConfig); err != nil {
 		return fmt.Errorf("failed to init watchdog clients: %w", err)
@@ -329,7 +878,7 @@ func reloadConfiguration() error {
 
 func canProceed() bool {
 	for _, client := range core.Clients {
-		if client.Updating {
+		if client.IsUpdating() {
 			return false
 		}
 	}
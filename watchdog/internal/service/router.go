@@ -31,6 +31,29 @@ func SetupRouter(cfg *model.YamlConfig, r *gin.Engine) *gin.Engine {
 		protected.GET("/toggle", getAlertToggle)
 		protected.POST("/config", setConfig)
 		protected.POST("/toggle", setAlertToggle)
+		protected.GET("/alerts", getAlerts)
+		protected.POST("/alerts/test", testAlertChannels)
+		protected.GET("/alerts/queue", getAlertQueue)
+		protected.GET("/alerts/dead-letter", getDeadLetterAlerts)
+		protected.POST("/alerts/dead-letter/:id/resend", resendDeadLetterAlert)
+		protected.GET("/report", getReport)
+		protected.GET("/forecasts", getSpaceForecasts)
+		protected.GET("/forecasts/:account/history", getSpaceHistory)
+		protected.GET("/balances/:account/history", getBalanceHistory)
+		protected.GET("/punishments", getPunishments)
+		protected.GET("/punishments/stats", getPunishmentStats)
+		protected.GET("/events", getMinerEvents)
+		protected.GET("/statuses", getStatusTimelines)
+		protected.GET("/statuses/:account/timeline", getStatusTimeline)
+		protected.GET("/uptime", getUptime)
+		protected.GET("/challenges", getChallenges)
+		protected.GET("/schedules", getSchedules)
+		protected.GET("/versions", getFleetVersions)
+		protected.GET("/components", getComponents)
+		protected.GET("/chain-nodes", getChainNodes)
+		protected.GET("/silences", getSilences)
+		protected.POST("/silences", createSilence)
+		protected.DELETE("/silences/:id", expireSilence)
 	}
 	return r
 }
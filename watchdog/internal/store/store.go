@@ -0,0 +1,43 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/CESSProject/watchdog/constant"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// mutex serializes writes so two collections saved at the same time never share a temp file
+var mutex sync.Mutex
+
+// Load reads the collection saved with name into v, a missing file leaves v untouched
+func Load(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(constant.DataPath, name+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Save writes v as json to the data directory, the file is replaced atomically
+func Save(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err = os.MkdirAll(constant.DataPath, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(constant.DataPath, name+".json")
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
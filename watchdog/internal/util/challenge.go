@@ -0,0 +1,24 @@
+package util
+
+import (
+	"github.com/CESSProject/cess-go-sdk/chain"
+	"github.com/CESSProject/watchdog/internal/model"
+)
+
+// ChallengeStatus converts the challenge snapshot of a miner queried at block into its proof deadlines,
+// challenged is false if the miner is not in the current challenge
+func ChallengeStatus(challenged bool, info chain.ChallengeInfo, block uint32) model.ChallengeStatus {
+	status := model.ChallengeStatus{Challenged: challenged, BlockId: block}
+	if !challenged {
+		return status
+	}
+	status.Start = uint32(info.ChallengeElement.Start)
+	status.IdleDeadline = uint32(info.ChallengeElement.IdleSlip)
+	status.ServiceDeadline = uint32(info.ChallengeElement.ServiceSlip)
+	status.VerifyDeadline = uint32(info.ChallengeElement.VerifySlip)
+	status.IdleSubmitted, _ = info.ProveInfo.IdleProve.Unwrap()
+	status.ServiceSubmitted, _ = info.ProveInfo.ServiceProve.Unwrap()
+	status.IdleBlocksLeft = int64(status.IdleDeadline) - int64(block)
+	status.ServiceBlocksLeft = int64(status.ServiceDeadline) - int64(block)
+	return status
+}
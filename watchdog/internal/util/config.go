@@ -0,0 +1,203 @@
+package util
+
+import (
+	"encoding/json"
+	"github.com/CESSProject/watchdog/internal/model"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+// updatableSections are the top level sections of the config file which can be updated by API,
+// external, port and auth are only changed in the file
+var updatableSections = map[string]bool{
+	"hosts":          true,
+	"scrapeInterval": true,
+	"schedules":      true,
+	"chain_query":    true,
+	"selectors":      true,
+	"alert":          true,
+	"report":         true,
+	"probe":          true,
+	"version":        true,
+	"uptime":         true,
+}
+
+// MergeConfig writes the sections of newConfig which are in the request body to the content of the config file,
+// the alert settings are written one by one, so the settings not sent keep their value in the file instead of
+// the defaults applied when the config was loaded
+func MergeConfig(config map[interface{}]interface{}, body []byte, newConfig model.YamlConfig) error {
+	var root, alert map[string]json.RawMessage
+	if err := json.Unmarshal(body, &root); err != nil {
+		return err
+	}
+	if raw, ok := root["alert"]; ok {
+		if err := json.Unmarshal(raw, &alert); err != nil {
+			return err
+		}
+	}
+	data, err := yaml.Marshal(newConfig)
+	if err != nil {
+		return err
+	}
+	var sections map[string]interface{}
+	if err = yaml.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+	for _, key := range sentYamlKeys(reflect.TypeOf(newConfig), root) {
+		if !updatableSections[key] {
+			continue
+		}
+		if key != "alert" {
+			setConfigValue(config, key, sections[key])
+			continue
+		}
+		merged := make(map[interface{}]interface{})
+		switch cur := config["alert"].(type) {
+		case map[string]interface{}:
+			for k, v := range cur {
+				merged[k] = v
+			}
+		case map[interface{}]interface{}:
+			for k, v := range cur {
+				merged[k] = v
+			}
+		}
+		newAlert, _ := sections["alert"].(map[string]interface{})
+		for _, alertKey := range sentYamlKeys(reflect.TypeOf(newConfig.Alert), alert) {
+			setConfigValue(merged, alertKey, newAlert[alertKey])
+		}
+		config["alert"] = merged
+	}
+	return nil
+}
+
+// sentYamlKeys returns the yaml keys of the fields of struct type t which are in the json object
+func sentYamlKeys(t reflect.Type, sent map[string]json.RawMessage) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonKey := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonKey == "" {
+			jsonKey = field.Name
+		}
+		if _, ok := sent[jsonKey]; !ok {
+			continue
+		}
+		yamlKey := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if yamlKey == "" {
+			yamlKey = strings.ToLower(field.Name)
+		}
+		keys = append(keys, yamlKey)
+	}
+	return keys
+}
+
+// setConfigValue sets the value of key, or removes key if the value is omitted as empty
+func setConfigValue(config map[interface{}]interface{}, key string, value interface{}) {
+	if value == nil {
+		delete(config, key)
+		return
+	}
+	config[key] = value
+}
+
+// KeepOmittedConfig keeps the current value of the optional alert and report settings which are not in the
+// request body, so a client which only knows the basic settings (e.g. the web ui) does not wipe them
+func KeepOmittedConfig(body []byte, newConfig *model.YamlConfig, cur model.YamlConfig) error {
+	var root, alert, email map[string]json.RawMessage
+	if err := json.Unmarshal(body, &root); err != nil {
+		return err
+	}
+	if raw, ok := root["alert"]; ok {
+		if err := json.Unmarshal(raw, &alert); err != nil {
+			return err
+		}
+	}
+	if raw, ok := alert["Email"]; ok {
+		if err := json.Unmarshal(raw, &email); err != nil {
+			return err
+		}
+	}
+	omitted := func(m map[string]json.RawMessage, key string) bool {
+		_, ok := m[key]
+		return !ok
+	}
+	if omitted(root, "report") {
+		newConfig.Report = cur.Report
+	}
+	var hosts []map[string]json.RawMessage
+	if raw, ok := root["hosts"]; ok {
+		if err := json.Unmarshal(raw, &hosts); err != nil {
+			return err
+		}
+	}
+	for i := range newConfig.Hosts {
+		if i >= len(hosts) {
+			continue
+		}
+		for _, host := range cur.Hosts {
+			if host.IP != newConfig.Hosts[i].IP {
+				continue
+			}
+			if omitted(hosts[i], "Group") {
+				newConfig.Hosts[i].Group = host.Group
+			}
+			if omitted(hosts[i], "Selectors") {
+				newConfig.Hosts[i].Selectors = host.Selectors
+			}
+			if omitted(hosts[i], "ChainRpcs") {
+				newConfig.Hosts[i].ChainRpcs = host.ChainRpcs
+			}
+		}
+	}
+	if omitted(root, "probe") {
+		newConfig.Probe = cur.Probe
+	}
+	if omitted(root, "version") {
+		newConfig.Version = cur.Version
+	}
+	if omitted(alert, "named_webhooks") {
+		newConfig.Alert.NamedWebhooks = cur.Alert.NamedWebhooks
+	}
+	if omitted(alert, "pagerduty") {
+		newConfig.Alert.PagerDuty = cur.Alert.PagerDuty
+	}
+	if omitted(alert, "opsgenie") {
+		newConfig.Alert.Opsgenie = cur.Alert.Opsgenie
+	}
+	if omitted(alert, "delivery") {
+		newConfig.Alert.Delivery = cur.Alert.Delivery
+	}
+	if omitted(alert, "maintenance_windows") {
+		newConfig.Alert.MaintenanceWindows = cur.Alert.MaintenanceWindows
+	}
+	if omitted(alert, "routes") {
+		newConfig.Alert.Routes = cur.Alert.Routes
+	}
+	if omitted(alert, "event_rules") {
+		newConfig.Alert.EventRules = cur.Alert.EventRules
+	}
+	if omitted(alert, "default_receivers") {
+		newConfig.Alert.DefaultReceivers = cur.Alert.DefaultReceivers
+	}
+	if omitted(alert, "thresholds") {
+		newConfig.Alert.Thresholds = cur.Alert.Thresholds
+	}
+	if omitted(email, "tls_mode") {
+		newConfig.Alert.Email.TLSMode = cur.Alert.Email.TLSMode
+	}
+	if omitted(email, "from") {
+		newConfig.Alert.Email.From = cur.Alert.Email.From
+	}
+	if omitted(email, "subject") {
+		newConfig.Alert.Email.Subject = cur.Alert.Email.Subject
+	}
+	if omitted(email, "cc") {
+		newConfig.Alert.Email.Cc = cur.Alert.Email.Cc
+	}
+	if omitted(email, "bcc") {
+		newConfig.Alert.Email.Bcc = cur.Alert.Email.Bcc
+	}
+	return nil
+}
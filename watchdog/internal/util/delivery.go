@@ -0,0 +1,54 @@
+package util
+
+import (
+	"errors"
+	"github.com/CESSProject/watchdog/internal/model"
+	"time"
+)
+
+// AlertBackoff doubles the initial wait time for each failed attempt up to max, plus up to 10% jitter,
+// r is a random number in [0, 1)
+func AlertBackoff(attempts int, initial time.Duration, max time.Duration, r float64) time.Duration {
+	backoff := initial
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff + time.Duration(float64(backoff)/10*r)
+}
+
+// FailAlertJob records a failed attempt of the job, it reports whether the job has used up maxAttempts
+// and should be moved to the dead-letter list, otherwise the next attempt is scheduled after the backoff
+func FailAlertJob(job *model.AlertJob, err error, now int64, maxAttempts int, backoff func(attempts int) time.Duration) bool {
+	job.Attempts++
+	job.LastError = err.Error()
+	job.UpdatedAt = now
+	var rejected *ReceiverError
+	if errors.As(err, &rejected) {
+		// the other receivers have got the alert, retry the rejected ones only
+		job.Receivers = rejected.Failed
+	}
+	if job.Attempts >= maxAttempts {
+		return true
+	}
+	job.NextAttempt = now + int64(backoff(job.Attempts).Seconds())
+	return false
+}
+
+// ResetAlertJob resets the attempts of a dead-letter job to send it again now
+func ResetAlertJob(job *model.AlertJob, now int64) {
+	job.Attempts = 0
+	job.NextAttempt = now
+	job.UpdatedAt = now
+}
+
+// PushDeadLetter appends the job to the dead-letter list and keeps the latest max jobs
+func PushDeadLetter(deadLetter []model.AlertJob, job model.AlertJob, max int) []model.AlertJob {
+	deadLetter = append(deadLetter, job)
+	if len(deadLetter) > max {
+		deadLetter = deadLetter[len(deadLetter)-max:]
+	}
+	return deadLetter
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/internal/model"
+	"strconv"
+	"strings"
+)
+
+// ParseDfOutput parses the output of `df -P -k <path> && df -P -i <path>`, the posix format keeps
+// each filesystem on one line: Filesystem, size, used, available, capacity and mount point
+func ParseDfOutput(output string) (model.DiskUsage, error) {
+	var usage model.DiskUsage
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Filesystem" {
+			continue
+		}
+		rows = append(rows, fields)
+	}
+	if len(rows) != 2 {
+		return usage, fmt.Errorf("unexpected df output: %q", output)
+	}
+	blocks, err := parseDfRow(rows[0])
+	if err != nil {
+		return usage, err
+	}
+	inodes, err := parseDfRow(rows[1])
+	if err != nil {
+		return usage, err
+	}
+	n := len(rows[0])
+	usage.Filesystem = strings.Join(rows[0][:n-5], " ")
+	usage.MountPoint = rows[0][n-1]
+	usage.Total = blocks[0] * 1024
+	usage.Used = blocks[1] * 1024
+	usage.Available = blocks[2] * 1024
+	if usage.Used+usage.Available > 0 {
+		usage.UsedPercent = float64(usage.Used) * 100 / float64(usage.Used+usage.Available)
+	}
+	usage.InodesTotal = inodes[0]
+	usage.InodesUsed = inodes[1]
+	if usage.InodesTotal > 0 {
+		usage.InodesUsedPercent = float64(usage.InodesUsed) * 100 / float64(usage.InodesTotal)
+	}
+	return usage, nil
+}
+
+// parseDfRow returns the size, used and available columns, the filesystem name may contain spaces
+func parseDfRow(fields []string) ([3]uint64, error) {
+	var res [3]uint64
+	n := len(fields)
+	for i := 0; i < 3; i++ {
+		// some filesystems (e.g. fuse) report "-" for inodes
+		if fields[n-5+i] == "-" {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[n-5+i], 10, 64)
+		if err != nil {
+			return res, fmt.Errorf("unexpected df output %q: %v", strings.Join(fields, " "), err)
+		}
+		res[i] = v
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// ParseEndpoint returns the host and port of an endpoint like http://1.2.3.4:8080, wss://rpc.example.com or 1.2.3.4:8080,
+// the port defaults to the one of the scheme
+func ParseEndpoint(endpoint string) (string, string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if !strings.Contains(endpoint, "://") {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+		}
+		return host, port, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid endpoint %q: %v", endpoint, err)
+	}
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = defaultPorts[strings.ToLower(u.Scheme)]
+	}
+	if host == "" || port == "" {
+		return "", "", fmt.Errorf("invalid endpoint %q: missing host or port", endpoint)
+	}
+	return host, port, nil
+}
+
+// IsLocalHost reports whether the host is localhost, a loopback or an unspecified ip
+func IsLocalHost(host string) bool {
+	ip := net.ParseIP(host)
+	return host == "localhost" || ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// IsLocalEndpoint reports whether the host of the endpoint is local, it is false for an invalid endpoint
+func IsLocalEndpoint(endpoint string) bool {
+	host, _, err := ParseEndpoint(endpoint)
+	return err == nil && IsLocalHost(host)
+}
+
+// HostEndpoint replaces a loopback host in the endpoint with the monitored host, since watchdog may run on another machine
+func HostEndpoint(host string, endpoint string) string {
+	h, _, err := ParseEndpoint(endpoint)
+	if err != nil || host == "" || !IsLocalHost(h) {
+		return endpoint
+	}
+	if strings.Contains(h, ":") {
+		h = "[" + h + "]"
+	}
+	return strings.Replace(endpoint, h, host, 1)
+}
+
+// ChainNodeEndpoints returns the rpc endpoints of the chain nodes on the host, the chain rpcs of the host take priority,
+// otherwise the default rpc port of the host if a chain container is selected
+func ChainNodeEndpoints(host string, chainRpcs []string, chainSelected bool) []string {
+	if len(chainRpcs) > 0 {
+		endpoints := make([]string, len(chainRpcs))
+		for i, endpoint := range chainRpcs {
+			endpoints[i] = HostEndpoint(host, endpoint)
+		}
+		return endpoints
+	}
+	if chainSelected {
+		return []string{"ws://" + net.JoinHostPort(host, strconv.Itoa(constant.ChainRpcPort))}
+	}
+	return nil
+}
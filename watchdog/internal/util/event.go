@@ -0,0 +1,34 @@
+package util
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"strings"
+)
+
+// minerCalls maps the normalized calls a miner signs to the kind of miner event
+var minerCalls = map[string]string{
+	"regnstk":                   constant.EventRegistration,
+	"regnstkassignstaking":      constant.EventRegistration,
+	"registerpoisk":             constant.EventRegistration,
+	"minerexitprep":             constant.EventExit,
+	"minerexit":                 constant.EventExit,
+	"minerwithdraw":             constant.EventWithdraw,
+	"submitidleproof":           constant.EventIdleProof,
+	"submitverifyidleresult":    constant.EventIdleProof,
+	"submitserviceproof":        constant.EventServiceProof,
+	"submitverifyserviceresult": constant.EventServiceProof,
+	"certidlespace":             constant.EventTagCertification,
+	"replaceidlespace":          constant.EventTagCertification,
+	"receivereward":             constant.EventRewardClaim,
+	"increasecollateral":        constant.EventCollateral,
+}
+
+// MinerEventKind returns the kind of miner event of an extrinsic like Sminer.miner_exit_prep or Audit.SubmitIdleProof,
+// empty if the call is not tracked
+func MinerEventKind(extrinsicName string) string {
+	name := extrinsicName
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return minerCalls[strings.ToLower(strings.ReplaceAll(name, "_", ""))]
+}
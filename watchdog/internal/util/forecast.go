@@ -0,0 +1,27 @@
+package util
+
+// LinearRegression fits y = slope*x + intercept by least squares, ok is false if there are
+// less than 2 points or all x are the same
+func LinearRegression(xs []float64, ys []float64) (slope float64, intercept float64, ok bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0, 0, false
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX float64
+	for i := range xs {
+		dx := xs[i] - meanX
+		cov += dx * (ys[i] - meanY)
+		varX += dx * dx
+	}
+	if varX == 0 {
+		return 0, 0, false
+	}
+	slope = cov / varX
+	return slope, meanY - slope*meanX, true
+}
@@ -1,9 +1,13 @@
 package util
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/CESSProject/watchdog/constant"
 	"github.com/go-resty/resty/v2"
+	"io"
+	"net/http"
 	"time"
 )
 
@@ -43,3 +47,41 @@ func (c *HTTPClient) Get(url string, result interface{}) error {
 func (c *HTTPClient) Post(url string, body interface{}, result interface{}) error {
 	return c.Request("POST", url, body, result)
 }
+
+// HTTPError is returned when the server responds with a non-2xx status code
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected response status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// PostJSON posts payload as json and returns the response status code,
+// a non-2xx response is returned as *HTTPError with the first 1KiB of the body
+func PostJSON(url string, headers map[string]string, payload interface{}) (int, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", constant.HttpPostContentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{Timeout: constant.HttpTimeout * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp.StatusCode, nil
+}
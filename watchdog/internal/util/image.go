@@ -0,0 +1,22 @@
+package util
+
+import "strings"
+
+// ParseImageRef splits an image reference like registry:5000/cesslab/cess-miner:testnet or
+// cesslab/cess-miner@sha256:... into the repository, tag and digest, the tag defaults to latest
+// if neither a tag nor a digest is given
+func ParseImageRef(ref string) (string, string, string) {
+	repository, digest := ref, ""
+	if i := strings.Index(ref, "@"); i >= 0 {
+		repository, digest = ref[:i], ref[i+1:]
+	}
+	tag := ""
+	// a colon after the last slash separates the tag, a colon before it belongs to the registry port
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+	return repository, tag, digest
+}
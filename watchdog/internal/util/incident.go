@@ -0,0 +1,161 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/model"
+	"net/url"
+	"strings"
+)
+
+// IncidentSender is implemented by on-call tools which keep an incident open
+// until the condition that triggered it clears.
+type IncidentSender interface {
+	Trigger(content model.AlertContent) (int, error)
+	Resolve(content model.AlertContent) (int, error)
+}
+
+// AlertDedupKey builds a stable key for an alert from host, account and alert kind,
+// so repeated alerts for the same condition are grouped into one incident.
+func AlertDedupKey(content model.AlertContent) string {
+	subject := content.SignatureAcc
+	if subject == "" {
+		subject = content.ContainerID
+	}
+	if subject == "" {
+		subject = "-"
+	}
+	return strings.Join([]string{constant.AlertSource, content.HostIp, subject, content.Kind}, "/")
+}
+
+type PagerDutyConfig struct {
+	RoutingKey string
+	EventsURL  string
+}
+
+func (conf *PagerDutyConfig) Trigger(content model.AlertContent) (int, error) {
+	summary := TruncateRunes(content.Description, 1024)
+	payload := map[string]interface{}{
+		"routing_key":  conf.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    AlertDedupKey(content),
+		"payload": map[string]interface{}{
+			"summary":   summary,
+			"source":    content.HostIp,
+			"severity":  pagerDutySeverity(content.Severity),
+			"component": content.SignatureAcc,
+			"group":     content.Kind,
+			"class":     content.Kind,
+			"custom_details": map[string]interface{}{
+				"alert_time":        content.AlertTime,
+				"signature_account": content.SignatureAcc,
+				"container_id":      content.ContainerID,
+				"block_number":      content.BlockNumber,
+			},
+		},
+	}
+	if content.DetailUrl != "" {
+		payload["links"] = []map[string]string{{"href": content.DetailUrl, "text": "Detail"}}
+	}
+	return PostJSON(conf.eventsURL(), nil, payload)
+}
+
+func (conf *PagerDutyConfig) Resolve(content model.AlertContent) (int, error) {
+	payload := map[string]interface{}{
+		"routing_key":  conf.RoutingKey,
+		"event_action": "resolve",
+		"dedup_key":    AlertDedupKey(content),
+	}
+	return PostJSON(conf.eventsURL(), nil, payload)
+}
+
+func (conf *PagerDutyConfig) eventsURL() string {
+	if conf.EventsURL == "" {
+		return constant.PagerDutyEventsURL
+	}
+	return conf.EventsURL
+}
+
+// pagerDutySeverity maps watchdog severity to one of critical, error, warning or info
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case constant.SeverityCritical, constant.SeverityError, constant.SeverityWarning, constant.SeverityInfo:
+		return severity
+	default:
+		return constant.SeverityError
+	}
+}
+
+type OpsgenieConfig struct {
+	ApiKey string
+	ApiURL string
+}
+
+func (conf *OpsgenieConfig) Trigger(content model.AlertContent) (int, error) {
+	message := TruncateRunes(content.Description, 130)
+	payload := map[string]interface{}{
+		"message":     message,
+		"alias":       AlertDedupKey(content),
+		"description": content.Description,
+		"source":      constant.AlertSource,
+		"entity":      content.HostIp,
+		"priority":    opsgeniePriority(content.Severity),
+		"tags":        []string{content.Kind, content.Severity},
+		"details": map[string]string{
+			"host":              content.HostIp,
+			"signature_account": content.SignatureAcc,
+			"container_id":      content.ContainerID,
+			"alert_time":        content.AlertTime,
+			"block_number":      fmt.Sprint(content.BlockNumber),
+		},
+	}
+	return PostJSON(conf.apiURL()+"/v2/alerts", conf.headers(), payload)
+}
+
+func (conf *OpsgenieConfig) Resolve(content model.AlertContent) (int, error) {
+	endpoint := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", conf.apiURL(), url.PathEscape(AlertDedupKey(content)))
+	payload := map[string]interface{}{
+		"source": constant.AlertSource,
+		"note":   "Condition cleared",
+	}
+	return PostJSON(endpoint, conf.headers(), payload)
+}
+
+func (conf *OpsgenieConfig) apiURL() string {
+	if conf.ApiURL == "" {
+		return constant.OpsgenieApiURL
+	}
+	return strings.TrimSuffix(conf.ApiURL, "/")
+}
+
+func (conf *OpsgenieConfig) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + conf.ApiKey}
+}
+
+// opsgeniePriority maps watchdog severity to Opsgenie priority P1-P5
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case constant.SeverityCritical:
+		return "P1"
+	case constant.SeverityError:
+		return "P2"
+	case constant.SeverityWarning:
+		return "P3"
+	case constant.SeverityInfo:
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// TruncateRunes cuts s to at most n characters without splitting a multi-byte character
+func TruncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
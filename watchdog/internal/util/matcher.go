@@ -0,0 +1,151 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/internal/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Matcher is an alert matcher with the regex compiled
+type Matcher struct {
+	model.AlertMatcher
+	re *regexp.Regexp
+}
+
+// CompileMatchers checks the label names and compiles the regex of the matchers
+func CompileMatchers(matchers []model.AlertMatcher, labelNames []string) ([]Matcher, error) {
+	res := make([]Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		known := false
+		for _, name := range labelNames {
+			if m.Name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown matcher name: %s, should be one of %s", m.Name, strings.Join(labelNames, ", "))
+		}
+		compiled := Matcher{AlertMatcher: m}
+		if m.IsRegex {
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex of matcher %s: %v", m.Name, err)
+			}
+			compiled.re = re
+		}
+		res = append(res, compiled)
+	}
+	return res, nil
+}
+
+// MatchLabels reports whether all matchers match the labels, a label may carry several values
+// (e.g. container id and container name) and matches if any of them matches
+func MatchLabels(labels map[string][]string, matchers []Matcher) bool {
+	for _, m := range matchers {
+		matched := false
+		for _, value := range labels[m.Name] {
+			if m.match(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Matcher) match(value string) bool {
+	if m.re != nil {
+		return m.re.MatchString(value)
+	}
+	return !m.IsRegex && m.Value == value
+}
+
+// InMaintenanceWindow reports whether t falls in the window, a window may span midnight
+func InMaintenanceWindow(window model.MaintenanceWindow, t time.Time) (bool, error) {
+	hour, minute, err := ParseClock(window.Start)
+	if err != nil {
+		return false, err
+	}
+	duration := time.Duration(window.Duration) * time.Minute
+	for offset := 0; offset <= int(duration/(24*time.Hour))+1; offset++ {
+		day := t.AddDate(0, 0, -offset)
+		start := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, t.Location())
+		if !windowOnWeekday(window.Weekdays, start.Weekday()) {
+			continue
+		}
+		if !t.Before(start) && t.Before(start.Add(duration)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ValidateMaintenanceWindow checks the start time, weekdays and duration of a window
+func ValidateMaintenanceWindow(window model.MaintenanceWindow) error {
+	if _, _, err := ParseClock(window.Start); err != nil {
+		return err
+	}
+	if window.Duration <= 0 {
+		return fmt.Errorf("duration of maintenance window %s must be greater than 0", window.Name)
+	}
+	for _, day := range window.Weekdays {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid weekday %s of maintenance window %s", day, window.Name)
+		}
+	}
+	return nil
+}
+
+func windowOnWeekday(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if d, ok := weekdays[strings.ToLower(day)]; ok && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWeekday parses the short name of a weekday, e.g. mon
+func ParseWeekday(day string) (time.Weekday, error) {
+	if d, ok := weekdays[strings.ToLower(day)]; ok {
+		return d, nil
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday %s", day)
+}
+
+// ParseClock parses a local time of day in 15:04 format
+func ParseClock(clock string) (int, int, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time %q, expected 15:04", clock)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour of time %q", clock)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute of time %q", clock)
+	}
+	return hour, minute, nil
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/model"
+	"strings"
+)
+
+// PunishmentReason classifies a punishment by the extrinsic it happened in, e.g. Audit.submit_verify_service_result.
+// A punishment out of any extrinsic happens when a challenge ends with a missing proof, challenge is the last known
+// challenge of the miner which tells whether the idle or the service proof is missing. The raw extrinsic name is
+// returned if it is unknown
+func PunishmentReason(extrinsicName string, challenge *model.ChallengeStatus) string {
+	name := strings.ToLower(extrinsicName)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.ReplaceAll(name, "_", "")
+	switch {
+	case strings.Contains(name, "verifyserviceresult"):
+		return constant.SvcProofResIncorrect
+	case strings.Contains(name, "verifyidleresult"):
+		return constant.IdleProofResIncorrect
+	case name == "":
+		if challenge != nil && challenge.Challenged && !challenge.IdleSubmitted {
+			return constant.NoSubmitIdleProof
+		}
+		return constant.NoSubmitSvcProof
+	default:
+		return extrinsicName
+	}
+}
+
+// IsMissingProof reports whether a punishment reason is a missing idle or service proof
+func IsMissingProof(reason string) bool {
+	return reason == constant.NoSubmitSvcProof || reason == constant.NoSubmitIdleProof
+}
+
+// IsIncorrectProof reports whether a punishment reason is an incorrect idle or service proof
+func IsIncorrectProof(reason string) bool {
+	return reason == constant.SvcProofResIncorrect || reason == constant.IdleProofResIncorrect
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows at most one event per interval, it never blocks the caller
+type RateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	mutex    sync.Mutex
+}
+
+// NewRateLimiter creates a limiter with the given events per minute, 0 means unlimited
+func NewRateLimiter(perMinute int) *RateLimiter {
+	var interval time.Duration
+	if perMinute > 0 {
+		interval = time.Minute / time.Duration(perMinute)
+	}
+	return &RateLimiter{interval: interval}
+}
+
+// Allow reports whether an event may happen now and reserves the slot if so
+func (r *RateLimiter) Allow() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	if now.Before(r.next) {
+		return false
+	}
+	r.next = now.Add(r.interval)
+	return true
+}
+
+// Wait blocks until an event may happen and reserves its slot, it returns the error of ctx if ctx is done first
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	at := time.Now()
+	if at.Before(r.next) {
+		at = r.next
+	}
+	r.next = at.Add(r.interval)
+	r.mutex.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the slot back if no later event has reserved the next one
+		r.mutex.Lock()
+		if r.next.Equal(at.Add(r.interval)) {
+			r.next = at
+		}
+		r.mutex.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// RewardDiff returns the reward accrued between two raw total rewards, formatted as BigNumConversion does,
+// a reward which can not be parsed or decreased counts as no reward
+func RewardDiff(prevRaw string, curRaw string) string {
+	prev, ok1 := new(big.Int).SetString(prevRaw, 10)
+	cur, ok2 := new(big.Int).SetString(curRaw, 10)
+	diff := new(big.Int)
+	if ok1 && ok2 && cur.Cmp(prev) > 0 {
+		diff.Sub(cur, prev)
+	}
+	return BigNumConversion(types.NewU128(*diff))
+}
+
+// BuildReportMessage renders a compact text form of the report for webhooks
+func BuildReportMessage(report model.Report) string {
+	var sb strings.Builder
+	sb.WriteString(report.Title)
+	sb.WriteString("\nPeriod: " + report.PeriodStart + " ~ " + report.PeriodEnd)
+	sb.WriteString(fmt.Sprintf("\nAlerts: %d", report.TotalAlerts))
+	severities := make([]string, 0, len(report.AlertCounts))
+	for severity := range report.AlertCounts {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	for _, severity := range severities {
+		sb.WriteString(fmt.Sprintf(", %s: %d", severity, report.AlertCounts[severity]))
+	}
+	for _, host := range report.Hosts {
+		sb.WriteString(fmt.Sprintf("\n\nHost: %s, uptime: %s, miners: %d, alerts: %d", host.Host, host.Uptime, len(host.Miners), host.Alerts))
+		for _, miner := range host.Miners {
+			sb.WriteString(fmt.Sprintf("\n- %s [%s] container: %s, uptime: %s", miner.Name, miner.Status, miner.ContainerState, miner.Uptime))
+			sb.WriteString(fmt.Sprintf("\n  idle: %s -> %s, service: %s -> %s",
+				miner.PrevIdleSpace, miner.IdleSpace, miner.PrevServiceSpace, miner.ServiceSpace))
+			sb.WriteString(fmt.Sprintf("\n  reward: +%s (total %s), punishments: %d, restarts: %d, alerts: %d",
+				miner.RewardAccrued, miner.TotalReward, miner.Punishments, miner.ContainerRestarts, miner.Alerts))
+		}
+	}
+	return sb.String()
+}
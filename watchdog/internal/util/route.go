@@ -0,0 +1,83 @@
+package util
+
+import (
+	"sort"
+
+	"github.com/CESSProject/watchdog/internal/model"
+)
+
+// Route is an alert route with the matchers compiled
+type Route struct {
+	model.AlertRoute
+	matchers []Matcher
+}
+
+// AlertChannel describes a configured channel for receiver selection
+type AlertChannel struct {
+	Name       string
+	Type       string
+	Resolvable bool
+}
+
+// CompileRoute compiles the matchers of an alert route
+func CompileRoute(route model.AlertRoute, labelNames []string) (Route, error) {
+	matchers, err := CompileMatchers(route.Matchers, labelNames)
+	if err != nil {
+		return Route{}, err
+	}
+	return Route{AlertRoute: route, matchers: matchers}, nil
+}
+
+// RouteReceivers evaluates the routes in order and returns the receivers of the labels, a matched route stops
+// the evaluation unless it sets continue, the labels no route matched go to the default receivers,
+// nil means all channels and an empty slice means the alert is dropped
+func RouteReceivers(labels map[string][]string, routes []Route, defaultReceivers []string) []string {
+	var receivers []string
+	matched := false
+	for _, route := range routes {
+		if !MatchLabels(labels, route.matchers) {
+			continue
+		}
+		matched = true
+		receivers = append(receivers, route.Receivers...)
+		if !route.Continue {
+			break
+		}
+	}
+	if !matched {
+		if len(defaultReceivers) == 0 {
+			return nil
+		}
+		return defaultReceivers
+	}
+	if receivers == nil {
+		return []string{}
+	}
+	return receivers
+}
+
+// SelectChannels returns the sorted names of the channels matching any of receivers (by name or type),
+// nil receivers means all channels, resolvable only for resolve events
+func SelectChannels(channels []AlertChannel, receivers []string, resolvableOnly bool) []string {
+	names := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if resolvableOnly && !ch.Resolvable {
+			continue
+		}
+		if receivers != nil && !containsReceiver(receivers, ch.Name) && !containsReceiver(receivers, ch.Type) {
+			continue
+		}
+		names = append(names, ch.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsReceiver(receivers []string, s string) bool {
+	for _, r := range receivers {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
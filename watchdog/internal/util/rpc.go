@@ -0,0 +1,94 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/CESSProject/watchdog/constant"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type jsonRpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CallJsonRpc calls a method of a substrate node by json-rpc over http, ws and wss urls are called
+// on the same port by http and https, which substrate nodes serve as well
+func CallJsonRpc(rpcURL string, method string, params []interface{}, result interface{}, timeout time.Duration) error {
+	switch {
+	case strings.HasPrefix(rpcURL, "ws://"):
+		rpcURL = "http://" + strings.TrimPrefix(rpcURL, "ws://")
+	case strings.HasPrefix(rpcURL, "wss://"):
+		rpcURL = "https://" + strings.TrimPrefix(rpcURL, "wss://")
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	payload, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(rpcURL, constant.HttpPostContentType, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	var res jsonRpcResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("invalid json-rpc response: %v", err)
+	}
+	if res.Error != nil {
+		return fmt.Errorf("json-rpc error %d: %s", res.Error.Code, res.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+// QueryRpcBlockHeight returns the best block number of a substrate node
+func QueryRpcBlockHeight(rpcURL string, timeout time.Duration) (uint64, error) {
+	var header struct {
+		Number string `json:"number"`
+	}
+	if err := CallJsonRpc(rpcURL, "chain_getHeader", nil, &header, timeout); err != nil {
+		return 0, err
+	}
+	return ParseHexNumber(header.Number)
+}
+
+// QueryFinalizedHeight returns the finalized block number of a substrate node
+func QueryFinalizedHeight(rpcURL string, timeout time.Duration) (uint64, error) {
+	var hash string
+	if err := CallJsonRpc(rpcURL, "chain_getFinalizedHead", nil, &hash, timeout); err != nil {
+		return 0, err
+	}
+	var header struct {
+		Number string `json:"number"`
+	}
+	if err := CallJsonRpc(rpcURL, "chain_getHeader", []interface{}{hash}, &header, timeout); err != nil {
+		return 0, err
+	}
+	return ParseHexNumber(header.Number)
+}
+
+// ParseHexNumber parses a 0x prefixed hex number
+func ParseHexNumber(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+}
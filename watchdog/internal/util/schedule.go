@@ -0,0 +1,11 @@
+package util
+
+import "time"
+
+// Jitter randomly shortens or extends interval by up to percent of it, r is a random number in [0, 1)
+func Jitter(interval time.Duration, percent int, r float64) time.Duration {
+	if percent <= 0 {
+		return interval
+	}
+	return interval + time.Duration(float64(interval)*float64(percent)/100*(2*r-1))
+}
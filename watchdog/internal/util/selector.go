@@ -0,0 +1,104 @@
+package util
+
+import (
+	"fmt"
+	"github.com/CESSProject/watchdog/internal/model"
+	"regexp"
+	"strings"
+)
+
+// MatchPattern matches s against a glob with * and ?, or against an anchored regex
+func MatchPattern(pattern string, isRegex bool, s string) bool {
+	re, err := compilePattern(pattern, isRegex)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+func compilePattern(pattern string, isRegex bool) (*regexp.Regexp, error) {
+	if !isRegex {
+		pattern = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// Selector is a container selector with the patterns compiled
+type Selector struct {
+	model.ContainerSelector
+	image   *regexp.Regexp
+	name    *regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// matchAllProbes are unrelated to any real image or name, a pattern matching all of them matches every container
+var matchAllProbes = []string{"x", "0/-:.@_"}
+
+// CompileSelector compiles the patterns of a selector, it rejects the invalid patterns and the selectors
+// which match every container
+func CompileSelector(selector model.ContainerSelector) (*Selector, error) {
+	res := &Selector{ContainerSelector: selector}
+	compile := func(pattern string) (*regexp.Regexp, error) {
+		if pattern == "" {
+			return nil, nil
+		}
+		re, err := compilePattern(pattern, selector.IsRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		return re, nil
+	}
+	var err error
+	if res.image, err = compile(selector.Image); err != nil {
+		return nil, err
+	}
+	if res.name, err = compile(selector.Name); err != nil {
+		return nil, err
+	}
+	for _, pattern := range selector.Exclude {
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re != nil {
+			res.exclude = append(res.exclude, re)
+		}
+	}
+	if len(selector.Labels) == 0 && matchesAll(res.image) && matchesAll(res.name) {
+		return nil, fmt.Errorf("selector of %s matches every container, set image, name or labels", selector.Component)
+	}
+	return res, nil
+}
+
+func matchesAll(re *regexp.Regexp) bool {
+	if re == nil {
+		return true
+	}
+	for _, s := range matchAllProbes {
+		if !re.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether a container with the image, name and labels is selected
+func (s *Selector) Match(image string, name string, labels map[string]string) bool {
+	if s.image != nil && !s.image.MatchString(image) {
+		return false
+	}
+	if s.name != nil && !s.name.MatchString(name) {
+		return false
+	}
+	for key, value := range s.Labels {
+		if v, ok := labels[key]; !ok || value != "" && v != value {
+			return false
+		}
+	}
+	for _, exclude := range s.exclude {
+		if exclude.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
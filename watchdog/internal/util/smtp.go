@@ -2,57 +2,257 @@ package util
 
 import (
 	"bytes"
+	"crypto/tls"
+	"embed"
+	"fmt"
 	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/log"
 	"github.com/CESSProject/watchdog/internal/model"
 	"gopkg.in/gomail.v2"
-	"text/template"
+	"html/template"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
 )
 
+// the default templates are built into the binary, a file with the same name in
+// constant.AlertStaticPath overrides the default one
+//
+//go:embed template.html report.html
+var defaultTemplates embed.FS
+
 type SmtpConfig struct {
-	SmtpUrl      string
-	SmtpPort     int
-	SenderAddr   string
-	SmtpPassword string
-	Receiver     []string
+	Host     string
+	Port     int
+	TLSMode  string // starttls, tls or none
+	Username string // no authentication if username or password is empty
+	Password string
+	From     string
+	To       []string
+	Cc       []string
+	Bcc      []string
+	Subject  string // text/template executed with model.AlertContent
+
+	once      sync.Once
+	templates map[string]*template.Template
+	subject   *texttemplate.Template
+}
+
+// ValidateSmtpConfig checks the tls mode, addresses and subject template of the mailer
+func ValidateSmtpConfig(conf *SmtpConfig) error {
+	switch conf.TLSMode {
+	case constant.SmtpTLSModeStartTLS, constant.SmtpTLSModeTLS, constant.SmtpTLSModeNone:
+	default:
+		return fmt.Errorf("invalid smtp tls mode %q, should be one of starttls, tls or none", conf.TLSMode)
+	}
+	if conf.Host == "" || conf.Port == 0 {
+		return fmt.Errorf("smtp endpoint and port are required")
+	}
+	// smtp.PlainAuth refuses to send the password over an unencrypted connection except to localhost
+	if conf.TLSMode == constant.SmtpTLSModeNone && conf.Username != "" && conf.Password != "" &&
+		conf.Host != "localhost" && conf.Host != "127.0.0.1" && conf.Host != "::1" {
+		return fmt.Errorf("smtp tls mode none can not authenticate to %s, use starttls or tls, or remove the smtp account and password", conf.Host)
+	}
+	if conf.From == "" {
+		return fmt.Errorf("smtp from address is required")
+	}
+	if len(conf.To)+len(conf.Cc)+len(conf.Bcc) == 0 {
+		return fmt.Errorf("at least one email receiver is required")
+	}
+	if _, err := texttemplate.New("subject").Parse(conf.Subject); err != nil {
+		return fmt.Errorf("invalid email subject template: %v", err)
+	}
+	return nil
+}
+
+// ReceiverError is returned when some receivers of an email are rejected, the other receivers have got the email
+type ReceiverError struct {
+	Failed []string
+	Err    error // the error of the last rejected receiver
 }
 
-func (conf *SmtpConfig) SendMail(content model.AlertContent) (err error) {
+func (e *ReceiverError) Error() string {
+	return fmt.Sprintf("receivers %s rejected: %v", strings.Join(e.Failed, ", "), e.Err)
+}
+
+func (conf *SmtpConfig) SendMail(content model.AlertContent) error {
+	return conf.SendMailTo(content, nil)
+}
 
-	t, err := template.ParseFiles(constant.AlertStaticPath + "template.html")
+// SendMailTo sends the alert to a part of the receivers, e.g. the ones rejected by the last attempt,
+// nil receivers means all receivers
+func (conf *SmtpConfig) SendMailTo(content model.AlertContent, receivers []string) error {
+	conf.loadTemplates()
+	var subject bytes.Buffer
+	if err := conf.subject.Execute(&subject, content); err != nil {
+		return fmt.Errorf("failed to execute email subject template: %v", err)
+	}
+	text, err := BuildMessage(content)
 	if err != nil {
-		log.Logger.Errorf("Error parsing template: %v", err)
 		return err
 	}
+	return conf.sendTemplate(constant.AlertTmpl, subject.String(), text, content, receivers)
+}
+
+// SendReport sends a digest report rendered by report.html
+func (conf *SmtpConfig) SendReport(report model.Report) error {
+	conf.loadTemplates()
+	return conf.sendTemplate(constant.ReportTmpl, report.Title, BuildReportMessage(report), report, nil)
+}
+
+// sendTemplate sends one multipart message with a plain text part and an html part to the receivers,
+// nil receivers means all receivers
+func (conf *SmtpConfig) sendTemplate(name string, subject string, text string, data interface{}, receivers []string) error {
+	t, ok := conf.templates[name]
+	if !ok {
+		return fmt.Errorf("email template %s not found", name)
+	}
 	var body bytes.Buffer
-	if err = t.Execute(&body, content); err != nil {
+	if err := t.Execute(&body, data); err != nil {
 		log.Logger.Errorf("Error executing template: %v", err)
 		return err
 	}
 
 	m := gomail.NewMessage()
-	m.SetHeader("From", conf.SenderAddr)
-	m.SetHeader("Subject: Storage Node Status Alert!")
-	m.SetBody("text/html", body.String())
-	d := gomail.NewDialer(conf.SmtpUrl, conf.SmtpPort, conf.SenderAddr, conf.SmtpPassword)
-	for _, receiver := range conf.Receiver {
-		m.SetHeader("To", receiver)
-		if err = sendEmailWithRetry(d, m); err != nil {
-			log.Logger.Errorf("Failed to send email to: %v: %v, retrying %d times", m.GetHeader("To"), err, constant.HttpMaxRetry)
-		} else {
-			log.Logger.Infof("Successfully sent email to %s", receiver)
+	m.SetHeader("From", conf.From)
+	if len(conf.To) > 0 {
+		m.SetHeader("To", conf.To...)
+	}
+	if len(conf.Cc) > 0 {
+		m.SetHeader("Cc", conf.Cc...)
+	}
+	m.SetHeader("Subject", subject)
+	m.SetDateHeader("Date", time.Now())
+	m.SetBody("text/plain", text)
+	m.AddAlternative("text/html", body.String())
+
+	recipients := receivers
+	if recipients == nil {
+		recipients = make([]string, 0, len(conf.To)+len(conf.Cc)+len(conf.Bcc))
+		recipients = append(recipients, conf.To...)
+		recipients = append(recipients, conf.Cc...)
+		recipients = append(recipients, conf.Bcc...)
+	}
+	if err := conf.send(m, recipients); err != nil {
+		log.Logger.Errorf("Failed to send email %q: %v", subject, err)
+		return err
+	}
+	log.Logger.Infof("Successfully sent email %q to %d receivers", subject, len(recipients))
+	return nil
+}
+
+// send delivers the message in one smtp session, starttls mode fails if the server does not support
+// STARTTLS instead of falling back to plain text, and none mode never upgrades the connection. The message
+// is still sent to the accepted receivers if some are rejected, a *ReceiverError lists the rejected ones
+func (conf *SmtpConfig) send(m *gomail.Message, recipients []string) error {
+	addr := net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+	tlsConfig := &tls.Config{ServerName: conf.Host}
+	dialer := &net.Dialer{Timeout: constant.SmtpTimeout * time.Second}
+	var conn net.Conn
+	var err error
+	if conf.TLSMode == constant.SmtpTLSModeTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if err = conn.SetDeadline(time.Now().Add(constant.SmtpTimeout * time.Second)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if conf.TLSMode == constant.SmtpTLSModeStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", addr)
+		}
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if conf.Username != "" && conf.Password != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support authentication", addr)
+		}
+		if err = c.Auth(smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(conf.From); err != nil {
+		return err
+	}
+	rejected := &ReceiverError{}
+	for _, rcpt := range recipients {
+		if err = c.Rcpt(rcpt); err != nil {
+			rejected.Failed = append(rejected.Failed, rcpt)
+			rejected.Err = err
 		}
 	}
-	return
+	if len(rejected.Failed) == len(recipients) {
+		return rejected
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = m.WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = c.Quit(); err != nil {
+		return err
+	}
+	if len(rejected.Failed) > 0 {
+		return rejected
+	}
+	return nil
 }
 
-func sendEmailWithRetry(d *gomail.Dialer, m *gomail.Message) (err error) {
-	for i := 0; i < constant.HttpMaxRetry; i++ {
-		if err = d.DialAndSend(m); err != nil {
-			log.Logger.Warnf("Fail when send email to: %v : %v, retrying (%d/%d)\n", m.GetHeader("To"), err, i+1, constant.HttpMaxRetry)
-		} else {
-			break
+// loadTemplates parses the email templates once, a template in constant.AlertStaticPath takes precedence
+// over the embedded one, templates are parsed again when the config is reloaded
+func (conf *SmtpConfig) loadTemplates() {
+	conf.once.Do(func() {
+		conf.templates = make(map[string]*template.Template)
+		for _, name := range []string{constant.AlertTmpl, constant.ReportTmpl} {
+			t, err := parseTemplate(name)
+			if err != nil {
+				log.Logger.Errorf("Error parsing template %s: %v", name, err)
+				continue
+			}
+			conf.templates[name] = t
+		}
+		subject := conf.Subject
+		if subject == "" {
+			subject = constant.SmtpDefSubject
 		}
+		t, err := texttemplate.New("subject").Parse(subject)
+		if err != nil {
+			log.Logger.Errorf("Error parsing email subject template, use the default one: %v", err)
+			t = texttemplate.Must(texttemplate.New("subject").Parse(constant.SmtpDefSubject))
+		}
+		conf.subject = t
+	})
+}
+
+func parseTemplate(name string) (*template.Template, error) {
+	path := constant.AlertStaticPath + name
+	if _, err := os.Stat(path); err == nil {
+		return template.ParseFiles(path)
 	}
-	return err
+	return template.ParseFS(defaultTemplates, name)
 }
@@ -0,0 +1,92 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/docker/docker/api/types"
+	"io"
+	"strings"
+)
+
+// DecodeContainerStats decodes a response of the docker container stats api, an empty or null response is an error
+func DecodeContainerStats(r io.Reader) (*types.StatsJSON, error) {
+	var v *types.StatsJSON
+	if err := json.NewDecoder(r).Decode(&v); err == io.EOF {
+		return nil, errors.New("empty container stats response")
+	} else if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, errors.New("null container stats response")
+	}
+	return v, nil
+}
+
+// StatsMetrics computes the cpu, memory, network, block I/O and PID metrics of the container stats
+func StatsMetrics(v *types.StatsJSON) model.ContainerMetrics {
+	// Usage must subtract cache-file-used, "file" on cgroup v2 and "cache" on cgroup v1
+	cache, ok := v.MemoryStats.Stats["file"]
+	if !ok {
+		cache = v.MemoryStats.Stats["cache"]
+	}
+	memUsage := v.MemoryStats.Usage
+	if memUsage > cache {
+		memUsage -= cache
+	}
+	metrics := model.ContainerMetrics{
+		CPUPercent:  cpuPercentUnix(v),
+		MemoryUsage: memUsage,
+		MemoryLimit: v.MemoryStats.Limit,
+		PIDs:        v.PidsStats.Current,
+	}
+	if metrics.MemoryLimit > 0 {
+		metrics.MemoryPercent = float64(memUsage) / float64(metrics.MemoryLimit) * 100.0
+	}
+	for _, network := range v.Networks {
+		metrics.NetworkRx += network.RxBytes
+		metrics.NetworkTx += network.TxBytes
+	}
+	for _, entry := range v.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			metrics.BlockRead += entry.Value
+		case "write":
+			metrics.BlockWrite += entry.Value
+		}
+	}
+	return metrics
+}
+
+func cpuPercentUnix(v *types.StatsJSON) float64 {
+	cpuDelta := float64(v.CPUStats.CPUUsage.TotalUsage) - float64(v.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(v.CPUStats.SystemUsage) - float64(v.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(v.CPUStats.OnlineCPUs)
+
+	if onlineCPUs == 0.0 {
+		onlineCPUs = float64(len(v.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if systemDelta > 0.0 && cpuDelta > 0.0 {
+		return (cpuDelta / systemDelta) * onlineCPUs * 100.0
+	}
+	return 0
+}
+
+// SetMetricRates computes the rates of the counters since the previous scrape, a counter reset
+// (e.g. the container restarted) gives a zero rate
+func SetMetricRates(cur *model.ContainerMetrics, prev *model.ContainerMetrics) {
+	if prev == nil || cur.UpdatedAt <= prev.UpdatedAt {
+		return
+	}
+	elapsed := float64(cur.UpdatedAt - prev.UpdatedAt)
+	rate := func(cur uint64, prev uint64) float64 {
+		if cur < prev {
+			return 0
+		}
+		return float64(cur-prev) / elapsed
+	}
+	cur.NetworkRxRate = rate(cur.NetworkRx, prev.NetworkRx)
+	cur.NetworkTxRate = rate(cur.NetworkTx, prev.NetworkTx)
+	cur.BlockReadRate = rate(cur.BlockRead, prev.BlockRead)
+	cur.BlockWriteRate = rate(cur.BlockWrite, prev.BlockWrite)
+}
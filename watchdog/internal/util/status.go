@@ -0,0 +1,25 @@
+package util
+
+import "github.com/CESSProject/watchdog/internal/model"
+
+// TimeInStatus sums the seconds spent in each status between from and to, the transitions are ordered by time
+// and the time before the first transition is not observed
+func TimeInStatus(transitions []model.StatusTransition, from int64, to int64) map[string]int64 {
+	res := make(map[string]int64)
+	for i, t := range transitions {
+		start, end := t.Time, to
+		if i+1 < len(transitions) {
+			end = transitions[i+1].Time
+		}
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		if end > start {
+			res[t.To] += end - start
+		}
+	}
+	return res
+}
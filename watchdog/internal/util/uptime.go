@@ -0,0 +1,57 @@
+package util
+
+import "github.com/CESSProject/watchdog/internal/model"
+
+// Uptime computes the percent of the time between from and to which is up, and of the miner time which is running,
+// positive and not punished. Each sample ordered by time holds until the next one but at most maxGap seconds, so the
+// time watchdog did not observe, e.g. when it was restarted, is left out instead of skewing the result
+func Uptime(samples []model.UptimeSample, from int64, to int64, maxGap int64, miner bool) model.UptimeWindow {
+	var window model.UptimeWindow
+	var up, running, positive, unpunished int64
+	for i, s := range samples {
+		if s.Time > to {
+			break
+		}
+		if s.Time >= from {
+			window.Samples++
+		}
+		start, end := s.Time, s.Time+maxGap
+		if i+1 < len(samples) && samples[i+1].Time < end {
+			end = samples[i+1].Time
+		}
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		if end <= start {
+			continue
+		}
+		seconds := end - start
+		window.Observed += seconds
+		if s.Up {
+			up += seconds
+		}
+		if s.Running {
+			running += seconds
+		}
+		if s.Positive {
+			positive += seconds
+		}
+		if !s.Punished {
+			unpunished += seconds
+		}
+	}
+	if window.Observed == 0 {
+		return window
+	}
+	percent := func(n int64) float64 { return float64(n) / float64(window.Observed) * 100 }
+	window.Uptime = percent(up)
+	if miner {
+		window.Running = percent(running)
+		window.Positive = percent(positive)
+		window.Unpunished = percent(unpunished)
+	}
+	return window
+}
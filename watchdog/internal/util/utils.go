@@ -1,6 +1,8 @@
 package util
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/CESSProject/cess-go-sdk/chain"
 	"github.com/CESSProject/watchdog/constant"
@@ -8,10 +10,12 @@ import (
 	"github.com/CESSProject/watchdog/internal/model"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"gopkg.in/yaml.v3"
+	"math"
 	"math/big"
 	"net"
 	"os"
 	"strings"
+	"time"
 )
 
 func ParseMinerConfigFile(data []byte) (model.MinerConfigFile, error) {
@@ -28,6 +32,12 @@ func TransferMinerInfoToMinerStat(info chain.MinerInfo) (model.MinerStat, error)
 	var minerStat = model.MinerStat{}
 	minerStat.Collaterals = BigNumConversion(types.U128(info.Collaterals))
 	minerStat.Debt = BigNumConversion(types.U128(info.Debt))
+	minerStat.CollateralsRaw = U128ToBigInt(types.U128(info.Collaterals)).String()
+	minerStat.DebtRaw = U128ToBigInt(types.U128(info.Debt)).String()
+	minerStat.DeclarationSpaceBytes = U128ToUint64(types.U128(info.DeclarationSpace))
+	minerStat.IdleSpaceBytes = U128ToUint64(types.U128(info.IdleSpace))
+	minerStat.ServiceSpaceBytes = U128ToUint64(types.U128(info.ServiceSpace))
+	minerStat.LockSpaceBytes = U128ToUint64(types.U128(info.LockSpace))
 	minerStat.Status = string(info.State)
 	minerStat.DeclarationSpace = StorageSpaceUnitConversion(types.U128(info.DeclarationSpace))
 	minerStat.IdleSpace = StorageSpaceUnitConversion(types.U128(info.IdleSpace))
@@ -43,12 +53,44 @@ func BigNumConversion(value types.U128) string {
 		return ""
 	}
 	bigRatValue := new(big.Rat).SetInt(bigIntValue)
-	divisor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+	divisor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(constant.TokenDecimals), nil))
 	result := new(big.Rat).Quo(bigRatValue, divisor)
 	resultFloat, _ := result.Float64()
 	return fmt.Sprintf("%.4f", resultFloat)
 }
 
+// U128ToBigInt returns a copy of the value, zero if unset
+func U128ToBigInt(value types.U128) *big.Int {
+	if value.Int == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(value.Int)
+}
+
+// U128ToUint64 returns the value as uint64, values out of range are capped at math.MaxUint64
+func U128ToUint64(value types.U128) uint64 {
+	v := U128ToBigInt(value)
+	if !v.IsUint64() {
+		return math.MaxUint64
+	}
+	return v.Uint64()
+}
+
+// ParseTokenAmount parses an amount of CESS like "4000" or "0.5" to the smallest unit
+func ParseTokenAmount(amount string) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("invalid token amount %q", amount)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(constant.TokenDecimals), nil)))
+	return new(big.Int).Quo(r.Num(), r.Denom()), nil
+}
+
+// FormatBytes formats a size like StorageSpaceUnitConversion
+func FormatBytes(v uint64) string {
+	return StorageSpaceUnitConversion(types.NewU128(*new(big.Int).SetUint64(v)))
+}
+
 func StorageSpaceUnitConversion(value types.U128) string {
 	var result string
 	if value.IsUint64() {
@@ -101,18 +143,6 @@ func LoadConfigFile(filePath string) (map[interface{}]interface{}, error) {
 	return configTemp, nil
 }
 
-func RemoveFields(config map[interface{}]interface{}, fields ...string) {
-	for _, field := range fields {
-		delete(config, field)
-	}
-}
-
-func AddFields(config map[interface{}]interface{}, conf model.YamlConfig) {
-	config["scrapeInterval"] = conf.ScrapeInterval
-	config["hosts"] = conf.Hosts
-	config["alert"] = conf.Alert
-}
-
 func SaveConfigFile(filePath string, config map[interface{}]interface{}) error {
 	data, err := yaml.Marshal(config)
 	if err != nil {
@@ -173,3 +203,12 @@ func GetLocalIP() string {
 	log.Logger.Errorf("Failed to get a valid local network interface addresses: %v", err)
 	return ""
 }
+
+// NewID returns a random 16 hex chars id
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,20 +1,17 @@
 package util
 
 import (
-	"bytes"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/CESSProject/watchdog/constant"
-	"github.com/CESSProject/watchdog/internal/log"
 	"github.com/CESSProject/watchdog/internal/model"
-	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 )
 
 type WebhookSender interface {
-	SendMessage(message string) error
+	SendMessage(message string) (int, error)
 }
 
 type DiscordWebhook struct {
@@ -22,7 +19,7 @@ type DiscordWebhook struct {
 	WebhookURL string
 }
 
-func (discord *DiscordWebhook) SendMessage(message string) error {
+func (discord *DiscordWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"content": message,
 	}
@@ -34,7 +31,7 @@ type TeamsWebhook struct {
 	WebhookURL string
 }
 
-func (teams *TeamsWebhook) SendMessage(message string) error {
+func (teams *TeamsWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"text": message,
 	}
@@ -46,7 +43,7 @@ type WechatWebhook struct {
 	WebhookURL string
 }
 
-func (wechat *WechatWebhook) SendMessage(message string) error {
+func (wechat *WechatWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"msgtype": "text",
 		"text": map[string]string{
@@ -61,7 +58,7 @@ type SlackWebhook struct {
 	WebhookURL string
 }
 
-func (slack *SlackWebhook) SendMessage(message string) error {
+func (slack *SlackWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"text": message,
 	}
@@ -73,7 +70,7 @@ type DingTalkWebhook struct {
 	WebhookURL string
 }
 
-func (ding *DingTalkWebhook) SendMessage(message string) error {
+func (ding *DingTalkWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"msgtype": "text",
 		"text": map[string]string{
@@ -88,7 +85,7 @@ type LarkWebhook struct {
 	WebhookURL string
 }
 
-func (lark *LarkWebhook) SendMessage(message string) error {
+func (lark *LarkWebhook) SendMessage(message string) (int, error) {
 	payload := map[string]interface{}{
 		"msg_type": "text",
 		"content": map[string]string{
@@ -98,87 +95,42 @@ func (lark *LarkWebhook) SendMessage(message string) error {
 	return sendWebhookRequest(lark.WebhookURL, payload)
 }
 
-func sendWebhookRequest(url string, payload interface{}) error {
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-	for j := 0; j < constant.HttpMaxRetry; j++ {
-		resp, err := http.Post(url, constant.HttpPostContentType, bytes.NewBuffer(jsonPayload))
-		if err != nil {
-			log.Logger.Warnf("Fail when request to webhook: %v, retrying (%d/%d)\n", err, j+1, constant.HttpMaxRetry)
-			continue
-		}
-		if resp.StatusCode != http.StatusOK {
-			log.Logger.Warnf("Unexceptional response status code: %d", resp.StatusCode)
-		}
-		err = resp.Body.Close()
-		if err != nil {
-			return err
-		}
-		if resp.StatusCode == http.StatusOK {
-			break
-		}
+func sendWebhookRequest(url string, payload interface{}) (int, error) {
+	return PostJSON(url, nil, payload)
+}
+
+// NewWebhookSender returns the sender matching the webhook provider of the url
+func NewWebhookSender(url string) (WebhookSender, error) {
+	switch GetWebhookType(url) {
+	case constant.Discord:
+		return &DiscordWebhook{url}, nil
+	case constant.Slack:
+		return &SlackWebhook{url}, nil
+	case constant.Teams:
+		return &TeamsWebhook{url}, nil
+	case constant.Lark:
+		return &LarkWebhook{url}, nil
+	case constant.DingTalk:
+		return &DingTalkWebhook{url}, nil
+	case constant.WeChat:
+		return &WechatWebhook{url}, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook type")
 	}
-	return nil
 }
 
-type WebhookConfig struct {
-	Webhooks []string
+// WebhookChannelName names a webhook by its type and a short hash of the url,
+// the name keeps stable when the webhook list is reordered and does not leak the url
+func WebhookChannelName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return GetWebhookType(url) + "-" + hex.EncodeToString(sum[:4])
 }
 
-func (conf *WebhookConfig) SendAlertToWebhook(content model.AlertContent) (err error) {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(conf.Webhooks))
-	message, err := buildMessage(content)
-	if err != nil {
-		log.Logger.Warnf("Can not build alert message: %v", err)
-		return
-	}
-	for _, url := range conf.Webhooks {
-		var hook WebhookSender
-		switch GetWebhookType(url) {
-		case "discord":
-			hook = &DiscordWebhook{url}
-			break
-		case "slack":
-			hook = &SlackWebhook{url}
-			break
-		case "teams":
-			hook = &TeamsWebhook{url}
-			break
-		case "lark":
-			hook = &LarkWebhook{url}
-			break
-		case "ding":
-			hook = &DingTalkWebhook{url}
-			break
-		case "wechat":
-			hook = &WechatWebhook{url}
-			break
-		default:
-			log.Logger.Warn("Unknown webhook type, cannot send webhook alert")
-			return nil
-		}
-		wg.Add(1)
-		go func(h WebhookSender) {
-			defer wg.Done()
-			if err := h.SendMessage(message); err != nil {
-				errChan <- err
-			}
-		}(hook)
-	}
-	wg.Wait()
-	close(errChan)
-	for err := range errChan {
-		if err != nil {
-			log.Logger.Errorf("Error sending webhook: %v", err)
-		}
-	}
-	return nil
+type WebhookConfig struct {
+	Webhooks []string
 }
 
-func buildMessage(content model.AlertContent) (string, error) {
+func BuildMessage(content model.AlertContent) (string, error) {
 	if content.AlertTime == "" || content.HostIp == "" || content.Description == "" {
 		return "", fmt.Errorf("cant build webhook msg with insufficient content")
 	}
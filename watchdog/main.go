@@ -1,16 +1,22 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/CESSProject/watchdog/docs"
 	"github.com/CESSProject/watchdog/internal/core"
 	"github.com/CESSProject/watchdog/internal/log"
 	"github.com/CESSProject/watchdog/internal/service"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"os"
 	"strconv"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "test-alert" {
+		os.Exit(testAlert(os.Args[2:]))
+	}
 	core.Run()
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
@@ -38,3 +44,34 @@ func main() {
 		log.Logger.Infof("Server running on port: %d", core.CustomConfig.Port)
 	}
 }
+
+// testAlert sends a test alert to the configured channels and prints the result of each channel,
+// usage: watchdog test-alert [channel name or type]
+func testAlert(args []string) int {
+	log.InitLogger()
+	if err := core.InitWatchdogConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	core.InitSmtpConfig()
+	core.InitWebhookConfig()
+	core.InitIncidentConfig()
+	core.InitAlertChannels()
+	var channel string
+	if len(args) > 0 {
+		channel = args[0]
+	}
+	results, err := core.SendTestAlert(channel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	output, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(output))
+	for _, result := range results {
+		if !result.Success {
+			return 1
+		}
+	}
+	return 0
+}
@@ -3,38 +3,29 @@ package test
 import (
 	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/model"
-	"log"
-	"net/http"
-	"strings"
+	"github.com/CESSProject/watchdog/internal/util"
+	"os"
 	"time"
 )
 
-func CallWebhook() error {
+// CallWebhook sends a sample alert to the webhook set by WATCHDOG_TEST_WEBHOOK,
+// it returns the http status code of the response
+func CallWebhook() (int, error) {
 	content := model.AlertContent{
 		AlertTime:   time.Now().Format(constant.TimeFormat),
 		HostIp:      "127.0.0.1",
 		ContainerID: "miner1",
+		Kind:        constant.AlertKindTest,
+		Severity:    constant.SeverityInfo,
 		Description: "The Storage Miner is not a positive status or get punishment",
 	}
-	sendData := `{
-		"msg_type": "text",
-		"content": {"text": "` + "CESS Information: " + " Alert Time: " + content.AlertTime + ", Host: " + content.HostIp + ", cID: " + content.ContainerID + ", Description: " + content.Description + `"}
-	}`
-	req, err := http.NewRequest("POST", "https://xxxx", strings.NewReader(sendData))
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	hook, err := util.NewWebhookSender(os.Getenv("WATCHDOG_TEST_WEBHOOK"))
 	if err != nil {
-		log.Printf("Call webhook failed!")
-	} else {
-		if resp.StatusCode != http.StatusOK {
-			log.Println("Unexceptional response status code: ", resp.StatusCode)
-		}
-		err = resp.Body.Close()
-		if err != nil {
-			return err
-		}
+		return 0, err
 	}
-	err = resp.Body.Close()
-	return nil
+	message, err := util.BuildMessage(content)
+	if err != nil {
+		return 0, err
+	}
+	return hook.SendMessage(message)
 }
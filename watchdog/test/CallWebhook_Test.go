@@ -1,12 +1,16 @@
 package test
 
 import (
+	"os"
 	"testing"
 )
 
 func TestCallWebhook(t *testing.T) {
-	err := CallWebhook()
+	if os.Getenv("WATCHDOG_TEST_WEBHOOK") == "" {
+		t.Skip("WATCHDOG_TEST_WEBHOOK is not set")
+	}
+	statusCode, err := CallWebhook()
 	if err != nil {
-		t.Fatal("Call webhook failed")
+		t.Fatalf("Call webhook failed, status code: %d, error: %v", statusCode, err)
 	}
 }
@@ -2,22 +2,30 @@ package test
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/CESSProject/watchdog/constant"
 	"github.com/CESSProject/watchdog/internal/model"
 	"gopkg.in/gomail.v2"
 	"html/template"
+	"os"
+	"strconv"
 	"time"
 )
 
+// SendMail sends a sample alert rendered by the alert template, the smtp server is set by
+// WATCHDOG_TEST_SMTP_HOST, WATCHDOG_TEST_SMTP_PORT, WATCHDOG_TEST_SMTP_USER, WATCHDOG_TEST_SMTP_PASSWORD
+// and the receiver by WATCHDOG_TEST_SMTP_TO
 func SendMail() error {
 	content := model.AlertContent{
 		AlertTime:     time.Now().Format(constant.TimeFormat),
 		HostIp:        "127.0.0.1",
 		ContainerName: "miner1",
+		Kind:          constant.AlertKindTest,
+		Severity:      constant.SeverityInfo,
 		Description:   "The Storage Miner is not a positive status or get punishment",
 	}
 
-	tmpl, err := template.ParseFiles("./internal/util/template.html")
+	tmpl, err := template.ParseFiles("../internal/util/" + constant.AlertTmpl)
 	if err != nil {
 		return err
 	}
@@ -27,16 +35,17 @@ func SendMail() error {
 		return err
 	}
 
+	port, err := strconv.Atoi(os.Getenv("WATCHDOG_TEST_SMTP_PORT"))
+	if err != nil {
+		return fmt.Errorf("invalid WATCHDOG_TEST_SMTP_PORT: %v", err)
+	}
+	user := os.Getenv("WATCHDOG_TEST_SMTP_USER")
 	m := gomail.NewMessage()
-	m.SetHeader("From", "xxxxx@cess.cloud")
-	m.SetHeader("To", "xxxxx@gmail.com")
-	m.SetHeader("Subject", "Hello!")
+	m.SetHeader("From", user)
+	m.SetHeader("To", os.Getenv("WATCHDOG_TEST_SMTP_TO"))
+	m.SetHeader("Subject", "Storage Node Status Alert!")
 	m.SetBody("text/html", body.String())
 
-	d := gomail.NewDialer("smtp.example.com", 80, "xxxxx@cess.cloud", "xxxxx")
-	if err = d.DialAndSend(m); err != nil {
-		return err
-	}
-	return nil
-
+	d := gomail.NewDialer(os.Getenv("WATCHDOG_TEST_SMTP_HOST"), port, user, os.Getenv("WATCHDOG_TEST_SMTP_PASSWORD"))
+	return d.DialAndSend(m)
 }
@@ -1,12 +1,15 @@
 package test
 
 import (
+	"os"
 	"testing"
 )
 
 func TestSendMail(t *testing.T) {
-	err := SendMail()
-	if err != nil {
-		t.Fatal("Send email with html template failed")
+	if os.Getenv("WATCHDOG_TEST_SMTP_HOST") == "" || os.Getenv("WATCHDOG_TEST_SMTP_TO") == "" {
+		t.Skip("WATCHDOG_TEST_SMTP_HOST or WATCHDOG_TEST_SMTP_TO is not set")
+	}
+	if err := SendMail(); err != nil {
+		t.Fatalf("Send email with html template failed: %v", err)
 	}
 }
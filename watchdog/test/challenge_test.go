@@ -0,0 +1,35 @@
+package test
+
+import (
+	"github.com/CESSProject/cess-go-sdk/chain"
+	"github.com/CESSProject/watchdog/internal/util"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallengeStatus(t *testing.T) {
+	status := util.ChallengeStatus(false, chain.ChallengeInfo{}, 1000)
+	assert.False(t, status.Challenged)
+	assert.Equal(t, uint32(1000), status.BlockId)
+	assert.Zero(t, status.IdleDeadline)
+
+	info := chain.ChallengeInfo{
+		ChallengeElement: chain.ChallengeElement{Start: 900, IdleSlip: 1100, ServiceSlip: 1050, VerifySlip: 1300},
+		ProveInfo: chain.ProveInfo{
+			IdleProve:    types.NewOption(chain.IdleProveInfo{}),
+			ServiceProve: types.NewEmptyOption[chain.ServiceProveInfo](),
+		},
+	}
+	status = util.ChallengeStatus(true, info, 1080)
+	assert.True(t, status.Challenged)
+	assert.Equal(t, uint32(900), status.Start)
+	assert.Equal(t, uint32(1100), status.IdleDeadline)
+	assert.Equal(t, uint32(1050), status.ServiceDeadline)
+	assert.Equal(t, uint32(1300), status.VerifyDeadline)
+	assert.True(t, status.IdleSubmitted)
+	assert.False(t, status.ServiceSubmitted)
+	assert.Equal(t, int64(20), status.IdleBlocksLeft)
+	assert.Equal(t, int64(-30), status.ServiceBlocksLeft)
+}
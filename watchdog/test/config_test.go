@@ -0,0 +1,178 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"gopkg.in/yaml.v3"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepOmittedConfig(t *testing.T) {
+	var cur model.YamlConfig
+	cur.Alert.MaintenanceWindows = []model.MaintenanceWindow{{Name: "weekly-upgrade", Start: "02:00", Duration: 120}}
+
+	tests := []struct {
+		name    string
+		body    string
+		windows int
+	}{
+		{"omitted", `{"alert": {"enable": true}}`, 1},
+		{"no alert", `{"scrapeInterval": 30}`, 1},
+		{"cleared", `{"alert": {"maintenance_windows": []}}`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newConfig model.YamlConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &newConfig))
+			require.NoError(t, util.KeepOmittedConfig([]byte(tt.body), &newConfig, cur))
+			assert.Len(t, newConfig.Alert.MaintenanceWindows, tt.windows)
+		})
+	}
+}
+
+func TestKeepOmittedHostConfig(t *testing.T) {
+	var cur model.YamlConfig
+	cur.Hosts = []model.HostItem{
+		{IP: "127.0.0.1", Port: "2375", Group: "dc-eu"},
+		{IP: "192.168.0.2", Port: "2375", Group: "dc-us"},
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		group []string
+	}{
+		{"omitted", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375"}, {"IP": "192.168.0.2", "Port": "2375"}]}`, []string{"dc-eu", "dc-us"}},
+		{"changed", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375", "Group": "dc-as"}]}`, []string{"dc-as"}},
+		{"cleared", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375", "Group": ""}]}`, []string{""}},
+		{"new host", `{"hosts": [{"IP": "10.0.0.3", "Port": "2375"}]}`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newConfig model.YamlConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &newConfig))
+			require.NoError(t, util.KeepOmittedConfig([]byte(tt.body), &newConfig, cur))
+			require.Len(t, newConfig.Hosts, len(tt.group))
+			for i, group := range tt.group {
+				assert.Equal(t, group, newConfig.Hosts[i].Group)
+			}
+		})
+	}
+}
+
+func TestKeepOmittedReportConfig(t *testing.T) {
+	var cur model.YamlConfig
+	cur.Report = model.ReportConfig{Enable: true, Schedule: "weekly", Time: "09:00"}
+
+	var newConfig model.YamlConfig
+	body := []byte(`{"alert": {"enable": true}}`)
+	require.NoError(t, json.Unmarshal(body, &newConfig))
+	require.NoError(t, util.KeepOmittedConfig(body, &newConfig, cur))
+	assert.Equal(t, cur.Report, newConfig.Report)
+
+	newConfig = model.YamlConfig{}
+	body = []byte(`{"report": {"enable": false}}`)
+	require.NoError(t, json.Unmarshal(body, &newConfig))
+	require.NoError(t, util.KeepOmittedConfig(body, &newConfig, cur))
+	assert.False(t, newConfig.Report.Enable)
+}
+
+func TestKeepOmittedChainRpcs(t *testing.T) {
+	var cur model.YamlConfig
+	cur.Hosts = []model.HostItem{{IP: "127.0.0.1", Port: "2375", ChainRpcs: []string{"ws://127.0.0.1:9944"}}}
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"omitted", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375"}]}`, []string{"ws://127.0.0.1:9944"}},
+		{"changed", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375", "ChainRpcs": ["ws://127.0.0.1:9945"]}]}`, []string{"ws://127.0.0.1:9945"}},
+		{"cleared", `{"hosts": [{"IP": "127.0.0.1", "Port": "2375", "ChainRpcs": null}]}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newConfig model.YamlConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &newConfig))
+			require.NoError(t, util.KeepOmittedConfig([]byte(tt.body), &newConfig, cur))
+			assert.Equal(t, tt.want, newConfig.Hosts[0].ChainRpcs)
+		})
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	file := func() map[interface{}]interface{} {
+		var config map[interface{}]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(`
+port: 13081
+scrapeInterval: 1800
+uptime:
+  target: 99.5
+alert:
+  enable: false
+  email:
+    smtp_endpoint: smtp.example.com
+  routes:
+    - name: critical
+      receivers: [ pagerduty ]
+`), &config))
+		return config
+	}
+	// the current config carries the defaults applied when loading
+	var cur model.YamlConfig
+	cur.Alert.Thresholds.RpcMaxLag = 20
+	cur.Alert.Delivery.MaxAttempts = 8
+
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, config map[interface{}]interface{}, alert map[interface{}]interface{})
+	}{
+		{"only sent alert settings", `{"alert": {"enable": true}}`, func(t *testing.T, config map[interface{}]interface{}, alert map[interface{}]interface{}) {
+			assert.Equal(t, true, alert["enable"])
+			assert.Contains(t, alert, "email")
+			assert.Contains(t, alert, "routes")
+			assert.NotContains(t, alert, "thresholds")
+			assert.NotContains(t, alert, "delivery")
+			assert.Equal(t, 1800, config["scrapeInterval"])
+		}},
+		{"cleared alert setting", `{"alert": {"routes": []}}`, func(t *testing.T, config map[interface{}]interface{}, alert map[interface{}]interface{}) {
+			assert.NotContains(t, alert, "routes")
+			assert.Equal(t, false, alert["enable"])
+		}},
+		{"new sections", `{"schedules": {"stats": 30}, "chain_query": {"workers": 2}, "uptime": {"target": 99.9}}`, func(t *testing.T, config map[interface{}]interface{}, alert map[interface{}]interface{}) {
+			assert.Equal(t, map[string]interface{}{"stats": 30}, config["schedules"])
+			assert.Equal(t, map[string]interface{}{"workers": 2}, config["chain_query"])
+			assert.Equal(t, map[string]interface{}{"target": 99.9}, config["uptime"])
+		}},
+		{"sections only changed in the file", `{"port": 8080, "auth": {"password": "x"}}`, func(t *testing.T, config map[interface{}]interface{}, alert map[interface{}]interface{}) {
+			assert.Equal(t, 13081, config["port"])
+			assert.NotContains(t, config, "auth")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newConfig model.YamlConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &newConfig))
+			require.NoError(t, util.KeepOmittedConfig([]byte(tt.body), &newConfig, cur))
+			config := file()
+			require.NoError(t, util.MergeConfig(config, []byte(tt.body), newConfig))
+			// the merged content is what is written to the file
+			data, err := yaml.Marshal(config)
+			require.NoError(t, err)
+			var written map[interface{}]interface{}
+			require.NoError(t, yaml.Unmarshal(data, &written))
+			alert := make(map[interface{}]interface{})
+			if m, ok := written["alert"].(map[string]interface{}); ok {
+				for k, v := range m {
+					alert[k] = v
+				}
+			}
+			tt.check(t, written, alert)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		r        float64
+		want     time.Duration
+	}{
+		{1, 0, 30 * time.Second},
+		{2, 0, time.Minute},
+		{3, 0, 2 * time.Minute},
+		{5, 0, 8 * time.Minute},
+		{6, 0, 10 * time.Minute}, // capped
+		{20, 0, 10 * time.Minute},
+		{1, 0.5, 30*time.Second + 1500*time.Millisecond},
+		{20, 0.99, 10*time.Minute + 59400*time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d-%v", tt.attempts, tt.r), func(t *testing.T) {
+			assert.Equal(t, tt.want, util.AlertBackoff(tt.attempts, 30*time.Second, 10*time.Minute, tt.r))
+		})
+	}
+}
+
+func TestFailAlertJob(t *testing.T) {
+	backoff := func(attempts int) time.Duration { return time.Duration(attempts) * time.Minute }
+	tests := []struct {
+		name        string
+		attempts    int
+		err         error
+		dead        bool
+		nextAttempt int64
+		receivers   []string
+	}{
+		{"first failure", 0, errors.New("timeout"), false, 1060, nil},
+		{"retry", 2, errors.New("timeout"), false, 1180, nil},
+		{"last attempt", 4, errors.New("timeout"), true, 0, nil},
+		{"rejected receivers", 1, &util.ReceiverError{Failed: []string{"b@example.com"}, Err: errors.New("550")}, false, 1120, []string{"b@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := model.AlertJob{ID: "1", Channel: "email", Attempts: tt.attempts}
+			dead := util.FailAlertJob(&job, tt.err, 1000, 5, backoff)
+			assert.Equal(t, tt.dead, dead)
+			assert.Equal(t, tt.attempts+1, job.Attempts)
+			assert.Equal(t, tt.err.Error(), job.LastError)
+			assert.Equal(t, int64(1000), job.UpdatedAt)
+			assert.Equal(t, tt.nextAttempt, job.NextAttempt)
+			assert.Equal(t, tt.receivers, job.Receivers)
+		})
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	var deadLetter []model.AlertJob
+	for i := 0; i < 5; i++ {
+		deadLetter = util.PushDeadLetter(deadLetter, model.AlertJob{ID: fmt.Sprint(i)}, 3)
+	}
+	assert.Len(t, deadLetter, 3)
+	assert.Equal(t, "2", deadLetter[0].ID)
+	assert.Equal(t, "4", deadLetter[2].ID)
+
+	// a resent job starts over with the rejected receivers kept
+	job := model.AlertJob{ID: "1", Attempts: 5, NextAttempt: 100, Receivers: []string{"b@example.com"}, LastError: "550"}
+	util.ResetAlertJob(&job, 2000)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Equal(t, int64(2000), job.NextAttempt)
+	assert.Equal(t, int64(2000), job.UpdatedAt)
+	assert.Equal(t, []string{"b@example.com"}, job.Receivers)
+}
@@ -0,0 +1,27 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDfOutput(t *testing.T) {
+	output := `Filesystem     1024-blocks      Used Available Capacity Mounted on
+/dev/sdb1       1000000000 900000000 100000000      90% /opt/miner/disk
+Filesystem        Inodes   IUsed     IFree IUse% Mounted on
+/dev/sdb1       61054976  610549  60444427    1% /opt/miner/disk`
+	usage, err := util.ParseDfOutput(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/sdb1", usage.Filesystem)
+	assert.Equal(t, "/opt/miner/disk", usage.MountPoint)
+	assert.Equal(t, uint64(1000000000*1024), usage.Total)
+	assert.Equal(t, uint64(900000000*1024), usage.Used)
+	assert.InDelta(t, 90, usage.UsedPercent, 0.01)
+	assert.Equal(t, uint64(61054976), usage.InodesTotal)
+	assert.InDelta(t, 1, usage.InodesUsedPercent, 0.01)
+
+	_, err = util.ParseDfOutput("df: /opt/miner/disk: No such file or directory")
+	assert.Error(t, err)
+}
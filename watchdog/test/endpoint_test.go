@@ -0,0 +1,80 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	cases := []struct {
+		endpoint   string
+		host, port string
+	}{
+		{"http://1.2.3.4:8080", "1.2.3.4", "8080"},
+		{"https://tee.example.com", "tee.example.com", "443"},
+		{"wss://rpc.example.com/ws", "rpc.example.com", "443"},
+		{"1.2.3.4:4001", "1.2.3.4", "4001"},
+		{"[::1]:9944", "::1", "9944"},
+	}
+	for _, c := range cases {
+		host, port, err := util.ParseEndpoint(c.endpoint)
+		assert.NoError(t, err, c.endpoint)
+		assert.Equal(t, c.host, host, c.endpoint)
+		assert.Equal(t, c.port, port, c.endpoint)
+	}
+	_, _, err := util.ParseEndpoint("1.2.3.4")
+	assert.Error(t, err)
+	_, _, err = util.ParseEndpoint("ftp://1.2.3.4")
+	assert.Error(t, err)
+}
+
+func TestParseHexNumber(t *testing.T) {
+	n, err := util.ParseHexNumber("0x1a2b")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x1a2b), n)
+	_, err = util.ParseHexNumber("0xzz")
+	assert.Error(t, err)
+}
+
+func TestChainNodeEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		host          string
+		chainRpcs     []string
+		chainSelected bool
+		want          []string
+	}{
+		{"host rpcs", "10.0.0.2", []string{"ws://10.0.0.2:9944", "wss://rpc.example.com"}, true, []string{"ws://10.0.0.2:9944", "wss://rpc.example.com"}},
+		{"loopback host rpc", "10.0.0.2", []string{"ws://127.0.0.1:9945"}, false, []string{"ws://10.0.0.2:9945"}},
+		{"localhost host rpc", "10.0.0.2", []string{"ws://localhost:9944"}, true, []string{"ws://10.0.0.2:9944"}},
+		{"fallback to the chain container", "10.0.0.2", nil, true, []string{"ws://10.0.0.2:9944"}},
+		{"no chain node", "10.0.0.2", nil, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.ChainNodeEndpoints(tt.host, tt.chainRpcs, tt.chainSelected))
+		})
+	}
+}
+
+func TestIsLocalEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"ws://127.0.0.1:9944", true},
+		{"ws://localhost:9944", true},
+		{"ws://[::1]:9944", true},
+		{"0.0.0.0:9944", true},
+		{"ws://10.0.0.2:9944", false},
+		{"wss://rpc.example.com", false},
+		{"127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.IsLocalEndpoint(tt.endpoint))
+		})
+	}
+}
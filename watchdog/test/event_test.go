@@ -0,0 +1,21 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinerEventKind(t *testing.T) {
+	assert.Equal(t, constant.EventRegistration, util.MinerEventKind("Sminer.regnstk"))
+	assert.Equal(t, constant.EventExit, util.MinerEventKind("Sminer.miner_exit_prep"))
+	assert.Equal(t, constant.EventWithdraw, util.MinerEventKind("Sminer.MinerWithdraw"))
+	assert.Equal(t, constant.EventIdleProof, util.MinerEventKind("Audit.submit_idle_proof"))
+	assert.Equal(t, constant.EventServiceProof, util.MinerEventKind("Audit.submit_verify_service_result"))
+	assert.Equal(t, constant.EventTagCertification, util.MinerEventKind("Sminer.cert_idle_space"))
+	assert.Equal(t, constant.EventRewardClaim, util.MinerEventKind("receive_reward"))
+	assert.Equal(t, "", util.MinerEventKind("Balances.transfer"))
+	assert.Equal(t, "", util.MinerEventKind(""))
+}
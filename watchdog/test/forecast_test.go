@@ -0,0 +1,20 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinearRegression(t *testing.T) {
+	slope, intercept, ok := util.LinearRegression([]float64{0, 1, 2, 3}, []float64{10, 8, 6, 4})
+	assert.True(t, ok)
+	assert.InDelta(t, -2, slope, 1e-9)
+	assert.InDelta(t, 10, intercept, 1e-9)
+
+	_, _, ok = util.LinearRegression([]float64{1}, []float64{1})
+	assert.False(t, ok)
+	_, _, ok = util.LinearRegression([]float64{1, 1}, []float64{1, 2})
+	assert.False(t, ok)
+}
@@ -0,0 +1,28 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageRef(t *testing.T) {
+	cases := []struct {
+		ref                     string
+		repository, tag, digest string
+	}{
+		{"cesslab/cess-miner:testnet", "cesslab/cess-miner", "testnet", ""},
+		{"cesslab/cess-miner", "cesslab/cess-miner", "latest", ""},
+		{"registry.local:5000/cesslab/cess-miner", "registry.local:5000/cesslab/cess-miner", "latest", ""},
+		{"registry.local:5000/cesslab/cess-miner:v0.8.0", "registry.local:5000/cesslab/cess-miner", "v0.8.0", ""},
+		{"cesslab/cess-miner@sha256:abcd", "cesslab/cess-miner", "", "sha256:abcd"},
+		{"cesslab/cess-miner:testnet@sha256:abcd", "cesslab/cess-miner", "testnet", "sha256:abcd"},
+	}
+	for _, c := range cases {
+		repository, tag, digest := util.ParseImageRef(c.ref)
+		assert.Equal(t, c.repository, repository, c.ref)
+		assert.Equal(t, c.tag, tag, c.ref)
+		assert.Equal(t, c.digest, digest, c.ref)
+	}
+}
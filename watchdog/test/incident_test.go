@@ -0,0 +1,32 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"short", "disk full", 130, "disk full"},
+		{"ascii", "disk full", 4, "disk"},
+		{"exact", "磁盘已满", 4, "磁盘已满"},
+		{"multi-byte", "磁盘已满", 2, "磁盘"},
+		{"mixed", "host 节点 down", 6, "host 节"},
+		{"zero", "disk", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := util.TruncateRunes(tt.s, tt.n)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
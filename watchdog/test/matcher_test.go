@@ -0,0 +1,61 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string][]string{
+		"host":      {"192.168.1.10"},
+		"container": {"0a1b2c", "miner1"},
+		"kind":      {"punishment"},
+	}
+	names := []string{"host", "container", "kind"}
+	tests := []struct {
+		name     string
+		matchers []model.AlertMatcher
+		want     bool
+	}{
+		{"no matcher", nil, true},
+		{"equal", []model.AlertMatcher{{Name: "host", Value: "192.168.1.10"}}, true},
+		{"regex of any value", []model.AlertMatcher{{Name: "container", Value: "miner[0-9]+", IsRegex: true}}, true},
+		{"anchored regex", []model.AlertMatcher{{Name: "container", Value: "miner", IsRegex: true}}, false},
+		{"all matchers", []model.AlertMatcher{{Name: "host", Value: "192.168.1.10"}, {Name: "kind", Value: "miner_status"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matchers, err := util.CompileMatchers(tt.matchers, names)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, util.MatchLabels(labels, matchers))
+		})
+	}
+
+	_, err := util.CompileMatchers([]model.AlertMatcher{{Name: "zone", Value: "eu"}}, names)
+	assert.Error(t, err)
+	_, err = util.CompileMatchers([]model.AlertMatcher{{Name: "host", Value: "(", IsRegex: true}}, names)
+	assert.Error(t, err)
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	window := model.MaintenanceWindow{Name: "night", Weekdays: []string{"sun"}, Start: "23:00", Duration: 120}
+	// 2024-06-02 is a Sunday
+	inWindow, err := util.InMaintenanceWindow(window, time.Date(2024, 6, 2, 23, 30, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.True(t, inWindow)
+	// the window spans midnight into Monday
+	inWindow, _ = util.InMaintenanceWindow(window, time.Date(2024, 6, 3, 0, 59, 0, 0, time.Local))
+	assert.True(t, inWindow)
+	inWindow, _ = util.InMaintenanceWindow(window, time.Date(2024, 6, 3, 1, 0, 0, 0, time.Local))
+	assert.False(t, inWindow)
+	inWindow, _ = util.InMaintenanceWindow(window, time.Date(2024, 6, 1, 23, 30, 0, 0, time.Local))
+	assert.False(t, inWindow)
+
+	_, err = util.InMaintenanceWindow(model.MaintenanceWindow{Start: "25:00", Duration: 10}, time.Now())
+	assert.Error(t, err)
+}
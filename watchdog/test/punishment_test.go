@@ -0,0 +1,39 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/constant"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPunishmentReason(t *testing.T) {
+	idleMissing := &model.ChallengeStatus{Challenged: true, ServiceSubmitted: true}
+	serviceMissing := &model.ChallengeStatus{Challenged: true, IdleSubmitted: true}
+	tests := []struct {
+		name      string
+		extrinsic string
+		challenge *model.ChallengeStatus
+		want      string
+	}{
+		{"service result", "Audit.submit_verify_service_result", nil, constant.SvcProofResIncorrect},
+		{"service result camel case", "Audit.SubmitVerifyServiceResult", nil, constant.SvcProofResIncorrect},
+		{"idle result", "Audit.submit_verify_idle_result", nil, constant.IdleProofResIncorrect},
+		{"idle result camel case", "Audit.SubmitVerifyIdleResult", serviceMissing, constant.IdleProofResIncorrect},
+		{"challenge end without challenge", "", nil, constant.NoSubmitSvcProof},
+		{"challenge end idle missing", "", idleMissing, constant.NoSubmitIdleProof},
+		{"challenge end service missing", "", serviceMissing, constant.NoSubmitSvcProof},
+		{"unknown extrinsic", "Timestamp.set", nil, "Timestamp.set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.PunishmentReason(tt.extrinsic, tt.challenge))
+		})
+	}
+	assert.True(t, util.IsMissingProof(constant.NoSubmitIdleProof))
+	assert.True(t, util.IsIncorrectProof(constant.SvcProofResIncorrect))
+	assert.False(t, util.IsMissingProof("Timestamp.set"))
+	assert.False(t, util.IsIncorrectProof("Timestamp.set"))
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"context"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := util.NewRateLimiter(1200) // one event per 50ms
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.False(t, limiter.Allow())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.Canceled)
+
+	unlimited := util.NewRateLimiter(0)
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, unlimited.Wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	limiter := util.NewRateLimiter(600) // one event per 100ms
+	assert.True(t, limiter.Allow())
+
+	// a done context neither waits nor reserves a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, limiter.Wait(ctx), context.Canceled)
+	}
+	// a context done while waiting gives its slot back
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, limiter.Wait(ctx))
+}
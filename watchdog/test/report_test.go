@@ -0,0 +1,30 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/CESSProject/watchdog/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewardDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		cur  string
+		want string
+	}{
+		{"accrued", "1000000000000000000", "3500000000000000000", "2.5000"},
+		{"beyond float64 precision", "123456789012345678901234", "123456789012345678901235", "0.0000"},
+		{"small amount", "123456789012345678901234", "123456889012345678901234", "0.1000"},
+		{"unchanged", "1000000000000000000", "1000000000000000000", "0.0000"},
+		{"decreased", "3500000000000000000", "1000000000000000000", "0.0000"},
+		{"no previous snapshot", "", "1000000000000000000", "0.0000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.RewardDiff(tt.prev, tt.cur))
+		})
+	}
+}
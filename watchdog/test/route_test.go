@@ -0,0 +1,76 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteReceivers(t *testing.T) {
+	names := []string{"host", "group", "kind"}
+	routes := []model.AlertRoute{
+		{Name: "punishment", Matchers: []model.AlertMatcher{{Name: "kind", Value: "punishment"}}, Receivers: []string{"pagerduty"}, Continue: true},
+		{Name: "prod", Matchers: []model.AlertMatcher{{Name: "group", Value: "prod"}}, Receivers: []string{"email"}},
+		{Name: "staging", Matchers: []model.AlertMatcher{{Name: "group", Value: "staging"}}, Receivers: []string{"slack"}},
+		{Name: "drop", Matchers: []model.AlertMatcher{{Name: "group", Value: "lab"}}},
+	}
+	compiled := make([]util.Route, 0, len(routes))
+	for _, route := range routes {
+		r, err := util.CompileRoute(route, names)
+		require.NoError(t, err)
+		compiled = append(compiled, r)
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string][]string
+		defaults []string
+		want     []string
+	}{
+		{"stop at first match", map[string][]string{"group": {"prod"}, "kind": {"miner_status"}}, nil, []string{"email"}},
+		{"continue to next match", map[string][]string{"group": {"prod"}, "kind": {"punishment"}}, nil, []string{"pagerduty", "email"}},
+		{"continue without next match", map[string][]string{"group": {"dev"}, "kind": {"punishment"}}, nil, []string{"pagerduty"}},
+		{"no match goes to default receivers", map[string][]string{"group": {"dev"}}, []string{"discord"}, []string{"discord"}},
+		{"no match without default receivers", map[string][]string{"group": {"dev"}}, nil, nil},
+		{"matched route without receiver", map[string][]string{"group": {"lab"}}, []string{"discord"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.RouteReceivers(tt.labels, compiled, tt.defaults))
+		})
+	}
+
+	_, err := util.CompileRoute(model.AlertRoute{Matchers: []model.AlertMatcher{{Name: "zone", Value: "eu"}}}, names)
+	assert.Error(t, err)
+}
+
+func TestSelectChannels(t *testing.T) {
+	channels := []util.AlertChannel{
+		{Name: "ops", Type: "slack"},
+		{Name: "email", Type: "email"},
+		{Name: "oncall", Type: "pagerduty", Resolvable: true},
+		{Name: "alertmanager", Type: "alertmanager", Resolvable: true},
+	}
+	tests := []struct {
+		name           string
+		receivers      []string
+		resolvableOnly bool
+		want           []string
+	}{
+		{"all channels", nil, false, []string{"alertmanager", "email", "oncall", "ops"}},
+		{"by name and type", []string{"ops", "pagerduty"}, false, []string{"oncall", "ops"}},
+		{"resolve to all resolvable channels", nil, true, []string{"alertmanager", "oncall"}},
+		{"resolve to routed resolvable channels", []string{"ops", "pagerduty"}, true, []string{"oncall"}},
+		{"resolve without resolvable receiver", []string{"email"}, true, []string{}},
+		{"unknown receiver", []string{"teams"}, false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, util.SelectChannels(channels, tt.receivers, tt.resolvableOnly))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitter(t *testing.T) {
+	assert.Equal(t, time.Minute, util.Jitter(time.Minute, 0, 0.9))
+	assert.Equal(t, time.Minute, util.Jitter(time.Minute, 10, 0.5))
+	assert.Equal(t, 54*time.Second, util.Jitter(time.Minute, 10, 0))
+	assert.Equal(t, 63*time.Second, util.Jitter(time.Minute, 10, 0.75))
+	for _, r := range []float64{0, 0.25, 0.5, 0.999} {
+		d := util.Jitter(10*time.Minute, 50, r)
+		assert.True(t, d >= 5*time.Minute && d < 15*time.Minute, d)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, util.MatchPattern("*cesslab/cess-miner*", false, "cesslab/cess-miner:testnet"))
+	assert.True(t, util.MatchPattern("*cesslab/cess-miner*", false, "registry.local:5000/cesslab/cess-miner"))
+	assert.False(t, util.MatchPattern("*cesslab/cess-miner*", false, "cesslab/cess-chain:testnet"))
+	assert.True(t, util.MatchPattern("miner?", false, "miner1"))
+	assert.False(t, util.MatchPattern("miner.", false, "miner1"))
+	assert.True(t, util.MatchPattern(`miner\d+`, true, "miner12"))
+	assert.False(t, util.MatchPattern(`miner\d+`, true, "old-miner12"))
+	assert.False(t, util.MatchPattern("(", true, "("))
+}
+
+func TestSelectorMatch(t *testing.T) {
+	selector, err := util.CompileSelector(model.ContainerSelector{
+		Image:   "*/cess-miner*",
+		Labels:  map[string]string{"com.cess.role": "miner", "com.cess.network": ""},
+		Exclude: []string{"*-backup"},
+	})
+	require.NoError(t, err)
+	labels := map[string]string{"com.cess.role": "miner", "com.cess.network": "testnet"}
+	assert.True(t, selector.Match("myfork/cess-miner:v1", "miner1", labels))
+	assert.False(t, selector.Match("myfork/cess-miner:v1", "miner1-backup", labels))
+	assert.False(t, selector.Match("myfork/cess-miner:v1", "miner1", map[string]string{"com.cess.role": "miner"}))
+	assert.False(t, selector.Match("myfork/cess-miner:v1", "miner1", map[string]string{"com.cess.role": "tee", "com.cess.network": "testnet"}))
+	assert.False(t, selector.Match("cesslab/cess-chain:testnet", "chain", labels))
+}
+
+func TestCompileSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector model.ContainerSelector
+		wantErr  bool
+	}{
+		{"image", model.ContainerSelector{Image: "*cesslab/cess-miner*"}, false},
+		{"name regex", model.ContainerSelector{Name: `miner\d+`, IsRegex: true}, false},
+		{"labels", model.ContainerSelector{Labels: map[string]string{"com.cess.role": "tee"}}, false},
+		{"match all labels", model.ContainerSelector{Image: "*", Labels: map[string]string{"com.cess.role": "tee"}}, false},
+		{"empty", model.ContainerSelector{Component: "tee"}, true},
+		{"match all glob", model.ContainerSelector{Image: "*"}, true},
+		{"match all regex", model.ContainerSelector{Name: ".+", IsRegex: true}, true},
+		{"match all exclude", model.ContainerSelector{Image: "*", Exclude: []string{"*-backup"}}, true},
+		{"invalid regex", model.ContainerSelector{Image: "(", IsRegex: true}, true},
+		{"invalid exclude", model.ContainerSelector{Image: "miner", Exclude: []string{"("}, IsRegex: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := util.CompileSelector(tt.selector)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"bufio"
+	"errors"
+	"github.com/CESSProject/watchdog/internal/log"
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSmtpServer accepts one session, rejects the receivers in reject and records the accepted ones
+func fakeSmtpServer(t *testing.T, reject map[string]bool) (int, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	accepted := make(chan []string, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		var rcpts []string
+		reply("220 fake")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "MAIL"):
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "RCPT"):
+				rcpt := strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+				if reject[rcpt] {
+					reply("550 no such user")
+					continue
+				}
+				rcpts = append(rcpts, rcpt)
+				reply("250 ok")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+				}
+				reply("250 ok")
+			case cmd == "QUIT":
+				reply("221 bye")
+				accepted <- rcpts
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, accepted
+}
+
+func TestSendMailToRejectedReceivers(t *testing.T) {
+	log.InitLogger()
+	port, accepted := fakeSmtpServer(t, map[string]bool{"b@example.com": true})
+	conf := &util.SmtpConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		TLSMode: "none",
+		From:    "watchdog@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Cc:      []string{"c@example.com"},
+		Subject: "[{{.Severity}}] {{.Kind}}",
+	}
+	content := model.AlertContent{AlertTime: "2026-01-01 00:00:00", HostIp: "127.0.0.1", Kind: "test", Severity: "info", Description: "test"}
+	err := conf.SendMailTo(content, nil)
+	var rejected *util.ReceiverError
+	require.True(t, errors.As(err, &rejected), "%v", err)
+	assert.Equal(t, []string{"b@example.com"}, rejected.Failed)
+	assert.Equal(t, []string{"a@example.com", "c@example.com"}, <-accepted)
+
+	// the retry sends to the rejected receivers only
+	port, accepted = fakeSmtpServer(t, nil)
+	conf.Port = port
+	require.NoError(t, conf.SendMailTo(content, rejected.Failed))
+	assert.Equal(t, []string{"b@example.com"}, <-accepted)
+}
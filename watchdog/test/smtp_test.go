@@ -0,0 +1,38 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSmtpConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    *util.SmtpConfig
+		wantErr bool
+	}{
+		{"tls with auth", &util.SmtpConfig{Host: "smtp.example.com", Port: 465, TLSMode: "tls", Username: "u", Password: "p"}, false},
+		{"starttls with auth", &util.SmtpConfig{Host: "smtp.example.com", Port: 587, TLSMode: "starttls", Username: "u", Password: "p"}, false},
+		{"none without auth", &util.SmtpConfig{Host: "smtp.example.com", Port: 25, TLSMode: "none"}, false},
+		{"none with auth to localhost", &util.SmtpConfig{Host: "localhost", Port: 25, TLSMode: "none", Username: "u", Password: "p"}, false},
+		{"none with auth", &util.SmtpConfig{Host: "smtp.example.com", Port: 25, TLSMode: "none", Username: "u", Password: "p"}, true},
+		{"invalid tls mode", &util.SmtpConfig{Host: "smtp.example.com", Port: 25, TLSMode: "ssl"}, true},
+		{"no endpoint", &util.SmtpConfig{Port: 25, TLSMode: "tls"}, true},
+		{"invalid subject", &util.SmtpConfig{Host: "smtp.example.com", Port: 465, TLSMode: "tls", Subject: "{{"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := tt.conf
+			conf.From = "watchdog@example.com"
+			conf.To = []string{"ops@example.com"}
+			err := util.ValidateSmtpConfig(conf)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statsResponse = `{
+	"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 2},
+	"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000},
+	"memory_stats": {"usage": 3072, "limit": 4096, "stats": {"file": 1024}},
+	"pids_stats": {"current": 12},
+	"networks": {"eth0": {"rx_bytes": 100, "tx_bytes": 200}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+	"blkio_stats": {"io_service_bytes_recursive": [{"op": "Read", "value": 300}, {"op": "Write", "value": 400}, {"op": "Total", "value": 700}]}
+}`
+
+func TestDecodeContainerStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"stats", statsResponse, false},
+		{"empty", "", true},
+		{"null", "null", true},
+		{"invalid", "{", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := util.DecodeContainerStats(strings.NewReader(tt.body))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, v)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, v)
+		})
+	}
+}
+
+func TestStatsMetrics(t *testing.T) {
+	v, err := util.DecodeContainerStats(strings.NewReader(statsResponse))
+	require.NoError(t, err)
+	metrics := util.StatsMetrics(v)
+	assert.Equal(t, 40.0, metrics.CPUPercent)
+	assert.Equal(t, uint64(2048), metrics.MemoryUsage)
+	assert.Equal(t, uint64(4096), metrics.MemoryLimit)
+	assert.Equal(t, 50.0, metrics.MemoryPercent)
+	assert.Equal(t, uint64(12), metrics.PIDs)
+	assert.Equal(t, uint64(101), metrics.NetworkRx)
+	assert.Equal(t, uint64(202), metrics.NetworkTx)
+	assert.Equal(t, uint64(300), metrics.BlockRead)
+	assert.Equal(t, uint64(400), metrics.BlockWrite)
+}
+
+func TestSetMetricRates(t *testing.T) {
+	prev := &model.ContainerMetrics{NetworkRx: 100, NetworkTx: 100, BlockRead: 1000, BlockWrite: 1000, UpdatedAt: 100}
+	tests := []struct {
+		name string
+		cur  model.ContainerMetrics
+		prev *model.ContainerMetrics
+		want [4]float64 // network rx, tx, block read, write
+	}{
+		{"rates", model.ContainerMetrics{NetworkRx: 300, NetworkTx: 100, BlockRead: 2000, BlockWrite: 1500, UpdatedAt: 110}, prev, [4]float64{20, 0, 100, 50}},
+		{"counter reset", model.ContainerMetrics{NetworkRx: 50, NetworkTx: 200, BlockRead: 10, BlockWrite: 1000, UpdatedAt: 110}, prev, [4]float64{0, 10, 0, 0}},
+		{"no previous scrape", model.ContainerMetrics{NetworkRx: 300, UpdatedAt: 110}, nil, [4]float64{}},
+		{"same time", model.ContainerMetrics{NetworkRx: 300, UpdatedAt: 100}, prev, [4]float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := tt.cur
+			util.SetMetricRates(&cur, tt.prev)
+			assert.Equal(t, tt.want, [4]float64{cur.NetworkRxRate, cur.NetworkTxRate, cur.BlockReadRate, cur.BlockWriteRate})
+		})
+	}
+}
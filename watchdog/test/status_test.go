@@ -0,0 +1,22 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeInStatus(t *testing.T) {
+	transitions := []model.StatusTransition{
+		{From: "", To: "positive", Time: 100},
+		{From: "positive", To: "frozen", Time: 400},
+		{From: "frozen", To: "positive", Time: 500},
+	}
+	assert.Equal(t, map[string]int64{"positive": 800, "frozen": 100}, util.TimeInStatus(transitions, 0, 1000))
+	assert.Equal(t, map[string]int64{"positive": 150, "frozen": 100}, util.TimeInStatus(transitions, 350, 600))
+	assert.Equal(t, map[string]int64{"frozen": 50}, util.TimeInStatus(transitions, 420, 470))
+	assert.Empty(t, util.TimeInStatus(transitions, 0, 50))
+	assert.Empty(t, util.TimeInStatus(nil, 0, 1000))
+}
@@ -0,0 +1,23 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenAmount(t *testing.T) {
+	amount, err := util.ParseTokenAmount("4000")
+	assert.NoError(t, err)
+	assert.Equal(t, "4000000000000000000000", amount.String())
+
+	amount, err = util.ParseTokenAmount("0.5")
+	assert.NoError(t, err)
+	assert.Equal(t, "500000000000000000", amount.String())
+
+	_, err = util.ParseTokenAmount("-1")
+	assert.Error(t, err)
+	_, err = util.ParseTokenAmount("abc")
+	assert.Error(t, err)
+}
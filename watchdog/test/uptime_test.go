@@ -0,0 +1,56 @@
+package test
+
+import (
+	"github.com/CESSProject/watchdog/internal/model"
+	"github.com/CESSProject/watchdog/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUptime(t *testing.T) {
+	samples := []model.UptimeSample{
+		{Time: 100, Up: true, Running: true, Positive: true},
+		{Time: 200, Up: false, Running: true, Positive: true, Punished: true},
+		{Time: 300, Up: false, Running: false},
+		{Time: 400, Up: true, Running: true, Positive: true},
+	}
+	window := util.Uptime(samples, 100, 500, 100, true)
+	assert.Equal(t, 4, window.Samples)
+	assert.Equal(t, int64(400), window.Observed)
+	assert.Equal(t, 50.0, window.Uptime)
+	assert.Equal(t, 75.0, window.Running)
+	assert.Equal(t, 75.0, window.Positive)
+	assert.Equal(t, 75.0, window.Unpunished)
+
+	// the sample before the window holds into it
+	window = util.Uptime(samples, 150, 350, 100, false)
+	assert.Equal(t, 2, window.Samples)
+	assert.Equal(t, int64(200), window.Observed)
+	assert.Equal(t, 25.0, window.Uptime)
+	assert.Equal(t, 0.0, window.Running)
+
+	assert.Equal(t, 0, util.Uptime(samples, 600, 1000, 100, true).Samples)
+	assert.Equal(t, int64(0), util.Uptime(samples, 600, 1000, 100, true).Observed)
+}
+
+func TestUptimeWeightsByTime(t *testing.T) {
+	// a down sample followed by a long up period is not half of the time
+	samples := []model.UptimeSample{
+		{Time: 0, Up: false},
+		{Time: 60, Up: true},
+		{Time: 3600, Up: true},
+	}
+	window := util.Uptime(samples, 0, 3660, 3600, false)
+	assert.Equal(t, int64(3660), window.Observed)
+	assert.InDelta(t, 98.36, window.Uptime, 0.01)
+
+	// a gap longer than maxGap, e.g. a restart of watchdog, is not observed
+	samples = []model.UptimeSample{
+		{Time: 0, Up: true},
+		{Time: 10000, Up: false},
+	}
+	window = util.Uptime(samples, 0, 10100, 100, false)
+	assert.Equal(t, int64(200), window.Observed)
+	assert.Equal(t, 50.0, window.Uptime)
+}
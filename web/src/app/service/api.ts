@@ -47,6 +47,16 @@ const authorizedRequest = async <T>(
     return requestFn();
 };
 
+export interface AlertQueryParams extends StandardPaginationParams {
+    host?: string;
+    account?: string;
+    severity?: string;
+    kind?: string;
+    status?: string;
+    from?: string | number;
+    to?: string | number;
+}
+
 export const api: any = {
     login: (params: any) => {
         return baseApiClient.post<ApiWrapper<any>>(`/login`, params);
@@ -73,4 +83,16 @@ export const api: any = {
     setConfig: (params: any) => {
         return authorizedRequest(() => baseApiClient.post<ApiWrapper<any>>(`/config`, params));
     },
+
+    getAlerts: (params: AlertQueryParams = {}) => {
+        const {pageIndex, pageSize, ...filters} = params;
+        const pagination = validateStandardPaginationParams({pageIndex, pageSize});
+        return authorizedRequest(() => {
+            return baseApiClient.get<ApiWrapper<any>>(`/alerts?${qs.stringify({
+                ...filters,
+                page: pagination.pageIndex,
+                page_size: pagination.pageSize
+            })}`);
+        });
+    },
 };
\ No newline at end of file